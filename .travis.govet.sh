@@ -1,7 +1,7 @@
 #!/bin/bash
 
 cd "$(dirname $0)"
-DIRS=". query"
+DIRS=". query migrate"
 set -e
 for subdir in $DIRS; do
   pushd $subdir
@@ -0,0 +1,270 @@
+package database
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/pkg/errors"
+
+	"github.com/dtucker2/database/query"
+)
+
+// Association manages the rows linked to a struct by one of its many-to-many relations, created using
+// Database.Association, e.g.
+//
+//	db.Association(&user, "Roles").Append(&role)
+//
+// Changes are made to the relation's join table and mirrored in the struct's relation field. The related rows
+// themselves must already exist, they are never inserted, updated or deleted.
+type Association struct {
+	db       *Database
+	tx       *Tx
+	owner    interface{}
+	ownerKey interface{}
+	relation *query.Relation
+	err      error
+}
+
+// Association returns an Association for the passed many-to-many relation (a field tagged 'rel:"many-to-many"') of
+// the passed object (struct pointer). Any error finding the relation is returned by the Association's methods.
+func (db *Database) Association(object interface{}, name string) *Association {
+	return newAssociation(db, nil, object, name)
+}
+
+// Association returns an Association for the passed many-to-many relation of the passed object (struct pointer),
+// whose changes are made within the transaction.
+func (tx *Tx) Association(object interface{}, name string) *Association {
+	return newAssociation(tx.db, tx, object, name)
+}
+
+func newAssociation(db *Database, tx *Tx, object interface{}, name string) *Association {
+	association := &Association{
+		db:    db,
+		tx:    tx,
+		owner: object,
+	}
+	metadata, err := query.GetMetadata(reflect.TypeOf(object))
+	if err != nil {
+		association.err = err
+		return association
+	}
+	relation, ok := metadata.Relation(name)
+	if !ok || relation.Kind != query.ManyToMany {
+		association.err = errors.Errorf("Unable to find many-to-many relation '%s' on '%s'.", name, metadata.Type)
+		return association
+	}
+	if len(metadata.Keys) != 1 {
+		association.err = errors.Errorf("Unable to associate '%s' (must have a single primary key field).", metadata.Type)
+		return association
+	}
+	association.relation = relation
+	association.ownerKey, _, ok = getRelationKey(metadata.Keys[0].Value(reflect.ValueOf(object).Elem()))
+	if !ok {
+		association.err = errors.Errorf("Unable to associate '%s' (primary key is NULL).", metadata.Type)
+	}
+	return association
+}
+
+// Load replaces the relation field of the struct with every related row linked to it.
+func (association *Association) Load() error {
+	return association.LoadContext(context.Background())
+}
+
+// LoadContext is the same as Load but executes the query using the passed context.
+func (association *Association) LoadContext(ctx context.Context) error {
+	if association.err != nil {
+		return association.err
+	}
+	if association.tx != nil {
+		ctx = association.tx.withContext(ctx)
+	}
+	related, _, err := association.db.findJoined(ctx, association.exec(), association.relation, []interface{}{association.ownerKey})
+	if err != nil {
+		return err
+	}
+	matches := make([]reflect.Value, related.Len())
+	for i := range matches {
+		matches[i] = related.Index(i)
+	}
+	setRelation(association.field(), association.relation, matches)
+	return nil
+}
+
+// Append links the passed related structs (struct pointers) to the struct.
+func (association *Association) Append(objects ...interface{}) error {
+	return association.AppendContext(context.Background(), objects...)
+}
+
+// AppendContext is the same as Append but executes the query using the passed context.
+func (association *Association) AppendContext(ctx context.Context, objects ...interface{}) error {
+	if len(objects) == 0 {
+		return association.err
+	}
+	elements, keys, err := association.getRelated(objects)
+	if err != nil {
+		return err
+	}
+	insert := func(exec executor) error {
+		return association.insertJoins(ctx, exec, keys)
+	}
+	// Keys split across several queries are linked within a transaction, so that either all or none of them are.
+	if len(keys) > association.joinChunkSize() {
+		err = association.withTransaction(ctx, insert)
+	} else {
+		err = insert(association.exec())
+	}
+	if err != nil {
+		return err
+	}
+	field := association.field()
+	setRelation(field, association.relation, append(getRelationElements(field), elements...))
+	return nil
+}
+
+// Replace unlinks every related row from the struct and links the passed related structs (struct pointers) instead.
+// Both changes are made within a transaction.
+func (association *Association) Replace(objects ...interface{}) error {
+	return association.ReplaceContext(context.Background(), objects...)
+}
+
+// ReplaceContext is the same as Replace but executes the queries using the passed context.
+func (association *Association) ReplaceContext(ctx context.Context, objects ...interface{}) error {
+	if association.err != nil {
+		return association.err
+	}
+	elements, keys, err := association.getRelated(objects)
+	if err != nil {
+		return err
+	}
+	err = association.withTransaction(ctx, func(exec executor) error {
+		query, args, err := association.db.BuildJoinDeleteQuery(association.relation, association.ownerKey, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := exec.ExecContext(ctx, query, args...); err != nil {
+			return newQueryError(err)
+		}
+		return association.insertJoins(ctx, exec, keys)
+	})
+	if err != nil {
+		return err
+	}
+	setRelation(association.field(), association.relation, elements)
+	return nil
+}
+
+// Remove unlinks the passed related structs (struct pointers) from the struct.
+func (association *Association) Remove(objects ...interface{}) error {
+	return association.RemoveContext(context.Background(), objects...)
+}
+
+// RemoveContext is the same as Remove but executes the query using the passed context.
+func (association *Association) RemoveContext(ctx context.Context, objects ...interface{}) error {
+	if len(objects) == 0 {
+		return association.err
+	}
+	_, keys, err := association.getRelated(objects)
+	if err != nil {
+		return err
+	}
+	metadata, err := query.GetMetadata(association.relation.Type)
+	if err != nil {
+		return err
+	}
+	query, args, err := association.db.BuildJoinDeleteQuery(association.relation, association.ownerKey, keys)
+	if err != nil {
+		return err
+	}
+	if _, err := association.exec().ExecContext(ctx, query, args...); err != nil {
+		return newQueryError(err)
+	}
+	removed := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		_, str, _ := getRelationKey(reflect.ValueOf(key))
+		removed[str] = true
+	}
+	field := association.field()
+	remaining := make([]reflect.Value, 0, field.Len())
+	for _, element := range getRelationElements(field) {
+		if _, key, ok := getRelationKey(metadata.Keys[0].Value(element.Elem())); !ok || !removed[key] {
+			remaining = append(remaining, element)
+		}
+	}
+	setRelation(field, association.relation, remaining)
+	return nil
+}
+
+// insertJoins links the owner to each of the passed related keys, splitting the keys across several queries when they
+// exceed the dialect's parameter limit.
+func (association *Association) insertJoins(ctx context.Context, exec executor, keys []interface{}) error {
+	chunkSize := association.joinChunkSize()
+	for start := 0; start < len(keys); start += chunkSize {
+		end := start + chunkSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		query, args, err := association.db.BuildJoinInsertQuery(association.relation, association.ownerKey, keys[start:end])
+		if err != nil {
+			return err
+		}
+		if _, err := exec.ExecContext(ctx, query, args...); err != nil {
+			return newQueryError(err)
+		}
+	}
+	return nil
+}
+
+// joinChunkSize returns the number of related keys linked by each join table insert, each taking two parameters.
+func (association *Association) joinChunkSize() int {
+	return association.db.Dialect().MaxParameters() / 2
+}
+
+// getRelated validates the passed related structs, returning pointers to them along with their primary keys.
+func (association *Association) getRelated(objects []interface{}) ([]reflect.Value, []interface{}, error) {
+	if association.err != nil {
+		return nil, nil, association.err
+	}
+	metadata, err := query.GetMetadata(association.relation.Type)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(metadata.Keys) != 1 {
+		return nil, nil, errors.Errorf("Unable to associate '%s' (must have a single primary key field).", metadata.Type)
+	}
+	elements := make([]reflect.Value, len(objects))
+	keys := make([]interface{}, len(objects))
+	for i, object := range objects {
+		val := reflect.ValueOf(object)
+		if val.Type() != reflect.PtrTo(association.relation.Type) || val.IsNil() {
+			return nil, nil, errors.Errorf("Unable to associate '%T' (expected '*%s').", object, association.relation.Type)
+		}
+		key, _, ok := getRelationKey(metadata.Keys[0].Value(val.Elem()))
+		if !ok {
+			return nil, nil, errors.Errorf("Unable to associate '%T' (primary key is NULL).", object)
+		}
+		elements[i] = val
+		keys[i] = key
+	}
+	return elements, keys, nil
+}
+
+// withTransaction runs fn within the association's transaction, or a new transaction when it has none.
+func (association *Association) withTransaction(ctx context.Context, fn func(exec executor) error) error {
+	if association.tx != nil {
+		return fn(association.tx.Tx)
+	}
+	return association.db.WithTransaction(ctx, func(tx *Tx) error {
+		return fn(tx.Tx)
+	})
+}
+
+func (association *Association) exec() executor {
+	if association.tx != nil {
+		return association.tx.Tx
+	}
+	return association.db.DB
+}
+
+func (association *Association) field() reflect.Value {
+	return reflect.ValueOf(association.owner).Elem().FieldByIndex(association.relation.Index)
+}
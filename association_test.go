@@ -0,0 +1,131 @@
+package database_test
+
+import (
+	. "github.com/dtucker2/database"
+	"github.com/dtucker2/database/query"
+
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type User struct {
+	Id    int    `name:"id" key:"true"`
+	Roles []Role `rel:"many-to-many" join:"user_roles" fk:"user_id" ref:"role_id"`
+}
+
+type Role struct {
+	Id   int    `name:"id" key:"true"`
+	Name string `name:"name"`
+}
+
+// smallDialect limits queries to four parameters, so that splitting queries can be tested with a few rows.
+type smallDialect struct {
+	query.Dialect
+}
+
+func (smallDialect) MaxParameters() int {
+	return 4
+}
+
+func TestDatabase_Association(t *testing.T) {
+	t.Run("load", func(t *testing.T) {
+		user := User{Id: 1}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("SELECT `Roles`.`id`,`Roles`.`name`,`user_roles`.`user_id` AS `joined_owner_key` FROM `Roles` " +
+			"INNER JOIN `user_roles` ON `user_roles`.`role_id`=`Roles`.`id` WHERE `user_roles`.`user_id` IN \\(\\?\\)").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "joined_owner_key"}).AddRow(3, "Admin", 1).AddRow(4, "Editor", 1))
+		require.NoError(t, NewDatabase(db).Association(&user, "Roles").Load())
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, []Role{{Id: 3, Name: "Admin"}, {Id: 4, Name: "Editor"}}, user.Roles)
+	})
+	t.Run("preload", func(t *testing.T) {
+		users := []*User{}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("SELECT `id` FROM `Users`").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+		mock.ExpectQuery("SELECT .* FROM `Roles` INNER JOIN `user_roles` .* IN \\(\\?,\\?\\)").
+			WithArgs(1, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "joined_owner_key"}).AddRow(3, "Admin", []byte("2")).AddRow(4, "Editor", int64(1)).AddRow(3, "Admin", int64(1)))
+		require.NoError(t, NewDatabase(db).Model(&User{}).Preload("Roles").Find(&users))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		require.Len(t, users, 2)
+		assert.Equal(t, []Role{{Id: 4, Name: "Editor"}, {Id: 3, Name: "Admin"}}, users[0].Roles)
+		assert.Equal(t, []Role{{Id: 3, Name: "Admin"}}, users[1].Roles)
+	})
+	t.Run("columns by name", func(t *testing.T) {
+		user := User{Id: 1}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("SELECT .* FROM `Roles` INNER JOIN `user_roles`").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"joined_owner_key", "name", "extra", "id"}).AddRow(1, "Admin", "unknown", 3))
+		require.NoError(t, NewDatabase(db, DiscardUnknownColumns()).Association(&user, "Roles").Load())
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, []Role{{Id: 3, Name: "Admin"}}, user.Roles)
+	})
+	t.Run("append in chunks", func(t *testing.T) {
+		user := User{Id: 1}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		// Each insert links at most two roles, so the three roles are linked by two inserts within a transaction.
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `user_roles` \\(`user_id`,`role_id`\\) VALUES \\(\\?,\\?\\),\\(\\?,\\?\\)$").
+			WithArgs(1, 3, 1, 4).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec("INSERT INTO `user_roles` \\(`user_id`,`role_id`\\) VALUES \\(\\?,\\?\\)$").
+			WithArgs(1, 5).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		association := NewDatabase(db, WithDialect(smallDialect{query.MySQL})).Association(&user, "Roles")
+		require.NoError(t, association.Append(&Role{Id: 3}, &Role{Id: 4}, &Role{Id: 5}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Len(t, user.Roles, 3)
+	})
+	t.Run("append and remove", func(t *testing.T) {
+		user := User{Id: 1, Roles: []Role{{Id: 3, Name: "Admin"}}}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectExec("INSERT INTO `user_roles` \\(`user_id`,`role_id`\\) VALUES \\(\\?,\\?\\),\\(\\?,\\?\\)").
+			WithArgs(1, 4, 1, 5).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec("DELETE FROM `user_roles` WHERE `user_id`=\\? AND `role_id` IN \\(\\?\\)").
+			WithArgs(1, 3).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		association := NewDatabase(db).Association(&user, "Roles")
+		require.NoError(t, association.Append(&Role{Id: 4, Name: "Editor"}, &Role{Id: 5, Name: "Viewer"}))
+		require.NoError(t, association.Remove(&Role{Id: 3}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, []Role{{Id: 4, Name: "Editor"}, {Id: 5, Name: "Viewer"}}, user.Roles)
+	})
+	t.Run("replace", func(t *testing.T) {
+		user := User{Id: 1, Roles: []Role{{Id: 3, Name: "Admin"}}}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM `user_roles` WHERE `user_id`=\\?$").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO `user_roles` \\(`user_id`,`role_id`\\) VALUES \\(\\?,\\?\\)").
+			WithArgs(1, 4).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		require.NoError(t, NewDatabase(db).Association(&user, "Roles").Replace(&Role{Id: 4, Name: "Editor"}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, []Role{{Id: 4, Name: "Editor"}}, user.Roles)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		user := User{Id: 1}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		database := NewDatabase(db)
+		assert.Error(t, database.Association(&user, "Missing").Load())
+		assert.Error(t, database.Association(&user, "Roles").Append(&user))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
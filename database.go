@@ -1,83 +1,702 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"reflect"
+	"sort"
 
 	"github.com/pkg/errors"
 
 	"github.com/dtucker2/database/query"
 )
 
-// Database wraps a sql.DB object and provides the ability to easily insert and update rows in a MySQL database using only a pointer to a struct.
+// Database wraps a sql.DB object and provides the ability to easily insert and update rows in a database using only a pointer to a struct.
+// Queries are generated for MySQL unless a different dialect is passed using WithDialect.
 type Database struct {
 	*sql.DB
 	*query.QueryBuilder
+
+	discardUnknownColumns bool
+	builderOptions        []query.Option
+	snapshots             *snapshots
+}
+
+// Option configures a Database.
+type Option func(*Database)
+
+// WithDialect sets the SQL dialect used to generate queries.
+func WithDialect(dialect query.Dialect) Option {
+	return func(db *Database) {
+		db.builderOptions = append(db.builderOptions, query.WithDialect(dialect))
+	}
+}
+
+// WithMaxQuerySize sets the approximate maximum size in bytes of the queries executed by InsertMany, which splits the
+// rows across several queries to keep within it. It defaults to 4MiB, the smallest default of MySQL's
+// max_allowed_packet, and should be raised or lowered to match the database server's limit.
+func WithMaxQuerySize(bytes int) Option {
+	return func(db *Database) {
+		db.builderOptions = append(db.builderOptions, query.WithMaxQuerySize(bytes))
+	}
+}
+
+// DiscardUnknownColumns causes result columns which are not mapped to a struct field to be discarded when scanning,
+// rather than returning an error.
+func DiscardUnknownColumns() Option {
+	return func(db *Database) {
+		db.discardUnknownColumns = true
+	}
+}
+
+// executor is satisfied by both sql.DB and sql.Tx, allowing queries to be run against either.
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
 }
 
 // NewDatabase returns a pointer to a new instance of the Database struct.
-func NewDatabase(db *sql.DB) *Database {
-	return &Database{
-		DB:           db,
-		QueryBuilder: query.NewQueryBuilder(),
+func NewDatabase(db *sql.DB, options ...Option) *Database {
+	database := &Database{
+		DB:        db,
+		snapshots: &snapshots{},
+	}
+	for _, option := range options {
+		option(database)
 	}
+	database.QueryBuilder = query.NewQueryBuilder(database.builderOptions...)
+	return database
 }
 
 // Insert constructs and executes an insert query on the database using only the passed pointer to a struct.
+// The generated auto-increment id and created_at timestamp are written back into the passed struct.
 func (db *Database) Insert(object interface{}) error {
-	query, args := db.BuildInsertQuery(object)
-	_, err := db.DB.Exec(query, args...)
+	return db.InsertContext(context.Background(), object)
+}
+
+// InsertContext is the same as Insert but executes the query using the passed context.
+func (db *Database) InsertContext(ctx context.Context, object interface{}) error {
+	return db.insert(ctx, db.DB, object)
+}
+
+// InsertMany constructs and executes multi-row insert queries on the database for every struct in the passed slice (or
+// pointer to a slice) of structs or struct pointers, returning the total number of rows inserted. The rows are split
+// across as few queries as the dialect's parameter limit and the maximum query size allow; when more than one query is
+// needed, or the structs implement BeforeInserter, they are executed within a transaction so that either every row or
+// no row is inserted.
+// The created_at timestamps and generated auto-increment ids are written back into the structs. Where the dialect
+// supports RETURNING (PostgreSQL and SQLite), neither of which guarantees the order of the returned rows, the returned
+// ids are sorted, relying on the database allocating increasing ids as it inserts the rows in order. Otherwise ids are
+// derived from the first id reported by LastInsertId, which assumes the database allocates consecutive ids to the rows
+// of a single query (true of MySQL unless innodb_autoinc_lock_mode is 2).
+func (db *Database) InsertMany(objects interface{}) (int64, error) {
+	return db.InsertManyContext(context.Background(), objects)
+}
+
+// InsertManyContext is the same as InsertMany but executes the queries using the passed context.
+func (db *Database) InsertManyContext(ctx context.Context, objects interface{}) (int64, error) {
+	slice, err := query.GetObjectSlice(objects)
 	if err != nil {
-		return errors.Wrap(err, "Failed to execute query.")
+		return 0, err
 	}
-	return nil
+	hooks := false
+	if slice.Len() > 0 {
+		_, hooks = query.ObjectAt(slice, 0).(BeforeInserter)
+	}
+	var batches []query.InsertBatch
+	if !hooks {
+		if batches, err = db.BuildInsertManyQueries(objects); err != nil {
+			return 0, err
+		}
+		if len(batches) <= 1 {
+			return db.executeInsertBatches(ctx, db.DB, objects, batches)
+		}
+	}
+	var affected int64
+	err = db.WithTransaction(ctx, func(tx *Tx) error {
+		ctx := tx.withContext(ctx)
+		if batches == nil {
+			// BeforeInsert hooks run within the transaction, before the queries are built from the values they set.
+			if batches, err = db.buildInsertBatches(ctx, objects); err != nil {
+				return err
+			}
+		}
+		affected, err = db.executeInsertBatches(ctx, tx.Tx, objects, batches)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return affected, nil
+}
+
+// Upsert constructs and executes an insert query on the database using only the passed pointer to a struct, updating
+// the existing row instead when one with the same primary key already exists. The existing row's created_at column is
+// preserved and its updated_at column refreshed. By default every other column is overwritten, this can be restricted
+// by passing the names of the columns to overwrite. The generated auto-increment id is written back into the struct.
+// A struct with a zero auto-increment id is matched to an existing row by its fields tagged 'unique:"true"', an error
+// being returned if it has none.
+func (db *Database) Upsert(object interface{}, columns ...string) error {
+	return db.UpsertContext(context.Background(), object, columns...)
+}
+
+// UpsertContext is the same as Upsert but executes the query using the passed context.
+func (db *Database) UpsertContext(ctx context.Context, object interface{}, columns ...string) error {
+	return db.upsert(ctx, db.DB, object, columns)
 }
 
 // Update constructs and executes an update query on the database using only the passed pointer to a struct.
+// Structs with a field tagged 'type:"version"' are only updated while the row's version matches the field, which is
+// incremented on success. ErrStaleObject is returned otherwise. Only changed columns are written for structs passed to
+// Track.
 func (db *Database) Update(object interface{}) error {
-	query, args, err := db.BuildUpdateQuery(object)
+	return db.UpdateContext(context.Background(), object)
+}
+
+// UpdateContext is the same as Update but executes the query using the passed context.
+func (db *Database) UpdateContext(ctx context.Context, object interface{}) error {
+	return db.update(ctx, db.DB, object)
+}
+
+// UpdateColumns constructs and executes an update query on the database which only writes the passed columns of the
+// passed pointer to a struct, along with its updated_at and version columns, leaving the row's other columns untouched.
+func (db *Database) UpdateColumns(object interface{}, columns ...string) error {
+	return db.UpdateColumnsContext(context.Background(), object, columns...)
+}
+
+// UpdateColumnsContext is the same as UpdateColumns but executes the query using the passed context.
+func (db *Database) UpdateColumnsContext(ctx context.Context, object interface{}, columns ...string) error {
+	return db.updateColumns(ctx, db.DB, object, columns)
+}
+
+// UpdateMap constructs and executes an update query on the database which writes the passed values, keyed by column
+// name, to the row of the passed pointer to a struct, along with its updated_at and version columns. Only the struct's
+// primary key and version fields are read, the passed values are not written back into the struct.
+func (db *Database) UpdateMap(object interface{}, values map[string]interface{}) error {
+	return db.UpdateMapContext(context.Background(), object, values)
+}
+
+// UpdateMapContext is the same as UpdateMap but executes the query using the passed context.
+func (db *Database) UpdateMapContext(ctx context.Context, object interface{}, values map[string]interface{}) error {
+	return db.updateMap(ctx, db.DB, object, values)
+}
+
+// Delete constructs and executes a delete query on the database using only the passed pointer to a struct.
+// The structs primary key field must be populated as this populates the 'WHERE' clause of the query.
+// Structs with a field tagged 'type:"deleted_at"' are soft deleted by setting the field to the current time.
+func (db *Database) Delete(object interface{}) error {
+	return db.DeleteContext(context.Background(), object)
+}
+
+// DeleteContext is the same as Delete but executes the query using the passed context.
+func (db *Database) DeleteContext(ctx context.Context, object interface{}) error {
+	return db.delete(ctx, db.DB, object)
+}
+
+// ForceDelete constructs and executes a delete query on the database using only the passed pointer to a struct,
+// physically deleting the row even when the struct supports soft deletes.
+func (db *Database) ForceDelete(object interface{}) error {
+	return db.ForceDeleteContext(context.Background(), object)
+}
+
+// ForceDeleteContext is the same as ForceDelete but executes the query using the passed context.
+func (db *Database) ForceDeleteContext(ctx context.Context, object interface{}) error {
+	return db.forceDelete(ctx, db.DB, object)
+}
+
+// Restore constructs and executes a query on the database restoring the soft deleted row of the passed pointer to a
+// struct, clearing its deleted_at field.
+func (db *Database) Restore(object interface{}) error {
+	return db.RestoreContext(context.Background(), object)
+}
+
+// RestoreContext is the same as Restore but executes the query using the passed context.
+func (db *Database) RestoreContext(ctx context.Context, object interface{}) error {
+	return db.restore(ctx, db.DB, object)
+}
+
+// Unscoped returns a copy of the database which ignores soft deletes: Delete physically deletes rows and Select, Find
+// and Count include soft deleted rows.
+func (db *Database) Unscoped() *Database {
+	clone := *db
+	clone.QueryBuilder = db.QueryBuilder.Unscoped()
+	return &clone
+}
+
+// Select constructs and executes a select query on the database using only the passed pointer to a struct.
+// The structs primary key field must be populated as this populates the 'WHERE' clause of the query.
+// The resulting row will be returned by reference in the passed struct.
+// Relations are loaded by passing Preload, e.g. db.Select(&order, Preload("Customer", "LineItems")).
+func (db *Database) Select(object interface{}, options ...SelectOption) error {
+	return db.SelectContext(context.Background(), object, options...)
+}
+
+// SelectContext is the same as Select but executes the query using the passed context.
+func (db *Database) SelectContext(ctx context.Context, object interface{}, options ...SelectOption) error {
+	return db.selectOne(ctx, db.DB, object, newSelectOptions(options))
+}
+
+// Find constructs and executes a select query on the database for every row matching the passed conditions.
+// Soft deleted rows are excluded, see Unscoped and Scope.WithTrashed.
+// The dest must be a pointer to a slice of structs or struct pointers, which is replaced with the resulting rows.
+// Conditions are a clause using '?' placeholders followed by its arguments, e.g. db.Find(&people, "age > ?", 30), or a
+// struct pointer whose non-zero fields are matched, e.g. db.Find(&people, &Person{Age: 30}).
+// Passing no conditions selects every row. See Model for ordering and limiting the rows found.
+func (db *Database) Find(dest interface{}, conditions ...interface{}) error {
+	return db.FindContext(context.Background(), dest, conditions...)
+}
+
+// FindContext is the same as Find but executes the query using the passed context.
+func (db *Database) FindContext(ctx context.Context, dest interface{}, conditions ...interface{}) error {
+	return db.find(ctx, db.DB, dest, conditions)
+}
+
+// Count constructs and executes a query on the database counting the rows of the passed model's (struct pointer) table
+// matching the passed conditions, which are the same as those accepted by Find. Soft deleted rows are excluded.
+func (db *Database) Count(model interface{}, conditions ...interface{}) (int64, error) {
+	return db.CountContext(context.Background(), model, conditions...)
+}
+
+// CountContext is the same as Count but executes the query using the passed context.
+func (db *Database) CountContext(ctx context.Context, model interface{}, conditions ...interface{}) (int64, error) {
+	return db.count(ctx, db.DB, model, newConditionCriteria(conditions))
+}
+
+func (db *Database) insert(ctx context.Context, exec executor, object interface{}) error {
+	if err := beforeInsert(ctx, object); err != nil {
+		return err
+	}
+	query, args, err := db.BuildInsertQuery(object)
 	if err != nil {
 		return err
 	}
-	if _, err := db.DB.Exec(query, args...); err != nil {
-		return errors.Wrap(err, "Failed to execute query.")
+	if err := db.executeInsert(ctx, exec, object, query, args); err != nil {
+		return err
+	}
+	db.SetInsertedTimestamps(object, args)
+	if err := db.refreshSnapshot(ctx, object); err != nil {
+		return err
+	}
+	return afterInsert(ctx, object)
+}
+
+func (db *Database) upsert(ctx context.Context, exec executor, object interface{}, columns []string) error {
+	if err := beforeInsert(ctx, object); err != nil {
+		return err
+	}
+	query, args, err := db.BuildUpsertQuery(object, columns...)
+	if err != nil {
+		return err
+	}
+	if err := db.executeInsert(ctx, exec, object, query, args); err != nil {
+		return err
+	}
+	if err := db.refreshSnapshot(ctx, object); err != nil {
+		return err
+	}
+	return afterInsert(ctx, object)
+}
+
+// executeInsert executes the passed insert query, writing the generated auto-increment id back into the passed object.
+func (db *Database) executeInsert(ctx context.Context, exec executor, object interface{}, query string, args []interface{}) error {
+	if ptr, ok := db.GetAutoIncrementPointer(object); ok && db.Dialect().SupportsReturning() {
+		if err := exec.QueryRowContext(ctx, query, args...).Scan(ptr); err != nil {
+			return newQueryError(err)
+		}
+		return nil
+	}
+	result, err := exec.ExecContext(ctx, query, args...)
+	if err != nil {
+		return newQueryError(err)
+	}
+	// Not all drivers support LastInsertId, in which case the auto-increment field is left untouched. An id of zero
+	// means no row was inserted (e.g. an upsert which updated an existing row).
+	if id, err := result.LastInsertId(); err == nil && id != 0 {
+		return db.SetAutoIncrementValue(object, id)
 	}
 	return nil
 }
 
-// Delete constructs and executes a delete query on the database using only the passed pointer to a struct.
-// The structs primary key field must be populated as this populates the 'WHERE' clause of the query.
-func (db *Database) Delete(object interface{}) error {
+// buildInsertBatches calls the BeforeInsert hook of every object before building the multi-row insert queries.
+func (db *Database) buildInsertBatches(ctx context.Context, objects interface{}) ([]query.InsertBatch, error) {
+	slice, err := query.GetObjectSlice(objects)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < slice.Len(); i++ {
+		if err := beforeInsert(ctx, query.ObjectAt(slice, i)); err != nil {
+			return nil, err
+		}
+	}
+	return db.BuildInsertManyQueries(objects)
+}
+
+// executeInsertBatches executes the passed multi-row insert queries, writing the generated auto-increment ids and
+// created_at timestamps back into the objects and calling their AfterInsert hooks, and returns the total number of rows
+// inserted.
+func (db *Database) executeInsertBatches(ctx context.Context, exec executor, objects interface{}, batches []query.InsertBatch) (int64, error) {
+	slice, err := query.GetObjectSlice(objects)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, batch := range batches {
+		affected, err := db.executeInsertBatch(ctx, exec, slice, batch)
+		if err != nil {
+			return total, err
+		}
+		total += affected
+		argsPerRow := len(batch.Args) / batch.Count
+		for i := 0; i < batch.Count; i++ {
+			db.SetInsertedTimestamps(query.ObjectAt(slice, batch.Offset+i), batch.Args[i*argsPerRow:(i+1)*argsPerRow])
+		}
+	}
+	for i := 0; i < slice.Len(); i++ {
+		if err := afterInsert(ctx, query.ObjectAt(slice, i)); err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+func (db *Database) executeInsertBatch(ctx context.Context, exec executor, slice reflect.Value, batch query.InsertBatch) (int64, error) {
+	if _, ok := db.GetAutoIncrementPointer(query.ObjectAt(slice, batch.Offset)); ok && db.Dialect().SupportsReturning() {
+		rows, err := exec.QueryContext(ctx, batch.Query, batch.Args...)
+		if err != nil {
+			return 0, newQueryError(err)
+		}
+		defer rows.Close()
+		ids := make([]int64, 0, batch.Count)
+		for rows.Next() {
+			if len(ids) >= batch.Count {
+				return int64(len(ids)), errors.New("Failed to read inserted ids (more rows returned than inserted).")
+			}
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				return int64(len(ids)), errors.Wrap(err, "Failed to read inserted id.")
+			}
+			ids = append(ids, id)
+		}
+		if err := rows.Err(); err != nil {
+			return int64(len(ids)), newQueryError(err)
+		}
+		// RETURNING rows are not guaranteed to be in insertion order (SQLite documents that they may not be), but ids
+		// are allocated in increasing order as the rows are inserted in the order of the VALUES list.
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		for i, id := range ids {
+			if err := db.SetAutoIncrementValue(query.ObjectAt(slice, batch.Offset+i), id); err != nil {
+				return int64(len(ids)), err
+			}
+		}
+		return int64(len(ids)), nil
+	}
+	result, err := exec.ExecContext(ctx, batch.Query, batch.Args...)
+	if err != nil {
+		return 0, newQueryError(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		// Assume every row was inserted when the driver does not report affected rows.
+		affected = int64(batch.Count)
+	}
+	// LastInsertId reports the id of the first row inserted by the query.
+	if id, err := result.LastInsertId(); err == nil && id != 0 {
+		for i := 0; i < batch.Count; i++ {
+			if err := db.SetAutoIncrementValue(query.ObjectAt(slice, batch.Offset+i), id+int64(i)); err != nil {
+				return affected, err
+			}
+		}
+	}
+	return affected, nil
+}
+
+func (db *Database) update(ctx context.Context, exec executor, object interface{}) error {
+	if err := beforeUpdate(ctx, object); err != nil {
+		return err
+	}
+	// The query is built after BeforeUpdate, which may change the object. AfterUpdate is still called when a tracked
+	// object has no changes to write, so that the hooks are always called in pairs.
+	query, args, err := db.buildTrackedUpdateQuery(object)
+	if err != nil {
+		return err
+	}
+	if query == "" {
+		return afterUpdate(ctx, object)
+	}
+	if err := db.executeUpdate(ctx, exec, object, query, args); err != nil {
+		return err
+	}
+	if err := db.refreshSnapshot(ctx, object); err != nil {
+		return err
+	}
+	return afterUpdate(ctx, object)
+}
+
+func (db *Database) updateColumns(ctx context.Context, exec executor, object interface{}, columns []string) error {
+	if err := beforeUpdate(ctx, object); err != nil {
+		return err
+	}
+	query, args, err := db.BuildUpdateColumnsQuery(object, columns...)
+	if err != nil {
+		return err
+	}
+	if err := db.executeUpdate(ctx, exec, object, query, args); err != nil {
+		return err
+	}
+	// Only the written columns now match the row, any other changes are still to be written.
+	if err := db.refreshSnapshotColumns(ctx, object, columns); err != nil {
+		return err
+	}
+	return afterUpdate(ctx, object)
+}
+
+func (db *Database) updateMap(ctx context.Context, exec executor, object interface{}, values map[string]interface{}) error {
+	if err := beforeUpdate(ctx, object); err != nil {
+		return err
+	}
+	query, args, err := db.BuildUpdateMapQuery(object, values)
+	if err != nil {
+		return err
+	}
+	if err := db.executeUpdate(ctx, exec, object, query, args); err != nil {
+		return err
+	}
+	return afterUpdate(ctx, object)
+}
+
+// executeUpdate executes the passed update query, incrementing the version field of the passed object on success.
+func (db *Database) executeUpdate(ctx context.Context, exec executor, object interface{}, query string, args []interface{}) error {
+	result, err := exec.ExecContext(ctx, query, args...)
+	if err != nil {
+		return newQueryError(err)
+	}
+	if err := checkRowsAffected(result); err != nil {
+		if db.HasVersion(object) {
+			// Incrementing the version always changes the row, so no rows affected means no row matched.
+			return ErrStaleObject
+		}
+		if db.Dialect().CountsChangedRows() {
+			// The row may exist with its values left unchanged by the update.
+			return db.checkExists(ctx, exec, object)
+		}
+		return err
+	}
+	return db.IncrementVersion(object)
+}
+
+// checkExists returns ErrNoRowsAffected when no row has the passed object's primary key.
+func (db *Database) checkExists(ctx context.Context, exec executor, object interface{}) error {
+	query, args, err := db.BuildExistsQuery(object)
+	if err != nil {
+		return err
+	}
+	var exists int
+	if err := exec.QueryRowContext(ctx, query, args...).Scan(&exists); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNoRowsAffected
+		}
+		return newQueryError(err)
+	}
+	return nil
+}
+
+// buildTrackedUpdateQuery builds an update query for the passed object, limited to the columns changed since its
+// snapshot when it is tracked. An empty query is returned when a tracked object has not changed.
+func (db *Database) buildTrackedUpdateQuery(object interface{}) (string, []interface{}, error) {
+	snapshot, ok := db.snapshots.load(object)
+	if !ok {
+		return db.BuildUpdateQuery(object)
+	}
+	return db.BuildUpdateChangesQuery(object, snapshot)
+}
+
+func (db *Database) delete(ctx context.Context, exec executor, object interface{}) error {
+	if err := beforeDelete(ctx, object); err != nil {
+		return err
+	}
 	query, args, err := db.BuildDeleteQuery(object)
 	if err != nil {
 		return err
 	}
-	if _, err := db.DB.Exec(query, args...); err != nil {
-		return errors.Wrap(err, "Failed to execute query.")
+	result, err := exec.ExecContext(ctx, query, args...)
+	if err != nil {
+		return newQueryError(err)
+	}
+	if err := checkRowsAffected(result); err != nil {
+		return err
+	}
+	if db.SoftDeletes(object) {
+		// The deletion time is the first argument of a soft delete query.
+		db.SetDeletedTimestamp(object, args[0])
 	}
+	db.untrack(ctx, object)
+	return afterDelete(ctx, object)
+}
+
+func (db *Database) forceDelete(ctx context.Context, exec executor, object interface{}) error {
+	if err := beforeDelete(ctx, object); err != nil {
+		return err
+	}
+	query, args, err := db.BuildForceDeleteQuery(object)
+	if err != nil {
+		return err
+	}
+	result, err := exec.ExecContext(ctx, query, args...)
+	if err != nil {
+		return newQueryError(err)
+	}
+	if err := checkRowsAffected(result); err != nil {
+		return err
+	}
+	db.untrack(ctx, object)
+	return afterDelete(ctx, object)
+}
+
+func (db *Database) restore(ctx context.Context, exec executor, object interface{}) error {
+	query, args, err := db.BuildRestoreQuery(object)
+	if err != nil {
+		return err
+	}
+	result, err := exec.ExecContext(ctx, query, args...)
+	if err != nil {
+		return newQueryError(err)
+	}
+	if err := checkRowsAffected(result); err != nil {
+		return err
+	}
+	db.SetDeletedTimestamp(object, nil)
 	return nil
 }
 
-// Select constructs and executes a select query on the database using only the passed pointer to a struct.
-// The structs primary key field must be populated as this populates the 'WHERE' clause of the query.
-// The resulting row will be returned by reference in the passed struct.
-func (db *Database) Select(object interface{}) error {
+func (db *Database) selectOne(ctx context.Context, exec executor, object interface{}, options *selectOptions) error {
 	query, args, err := db.BuildSelectQuery(object)
 	if err != nil {
 		return err
 	}
-	row := db.DB.QueryRow(query, args...)
-	if err := row.Scan(db.getFieldPointers(object)...); err != nil {
-		return errors.Wrap(err, "Failed to execute query.")
+	rows, err := exec.QueryContext(ctx, query, args...)
+	if err != nil {
+		return newQueryError(err)
 	}
-	return nil
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return newQueryError(err)
+		}
+		return &QueryError{Err: sql.ErrNoRows, Kind: ErrNotFound}
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return errors.Wrap(err, "Failed to read columns.")
+	}
+	if err := db.scanRow(rows, columns, object); err != nil {
+		return err
+	}
+	if err := db.refreshSnapshot(ctx, object); err != nil {
+		return err
+	}
+	// The rows are closed first, as some drivers cannot run a query (e.g. from a hook) while another's rows are open.
+	rows.Close()
+	if err := afterSelect(ctx, object); err != nil {
+		return err
+	}
+	owners := reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(object)), 0, 1)
+	return db.preload(ctx, exec, reflect.Append(owners, reflect.ValueOf(object)), options.preloads)
 }
 
-func (db *Database) getFieldPointers(object interface{}) []interface{} {
-	val := reflect.ValueOf(object).Elem()
-	ptrs := make([]interface{}, 0)
-	for i := 0; i < val.NumField(); i++ {
-		ptrs = append(ptrs, val.Field(i).Addr().Interface())
+func (db *Database) find(ctx context.Context, exec executor, dest interface{}, conditions []interface{}) error {
+	return db.findCriteria(ctx, exec, nil, dest, newConditionCriteria(conditions), nil)
+}
+
+func (db *Database) count(ctx context.Context, exec executor, model interface{}, criteria *query.Criteria) (int64, error) {
+	query, args, err := db.BuildCountQuery(model, criteria)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	if err := exec.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, newQueryError(err)
 	}
-	return ptrs
+	return count, nil
+}
+
+// newConditionCriteria returns criteria for the conditions passed to Find and Count: a clause followed by its
+// arguments, a struct pointer, or nothing.
+func newConditionCriteria(conditions []interface{}) *query.Criteria {
+	criteria := query.NewCriteria()
+	if len(conditions) > 0 {
+		criteria = criteria.Where(conditions[0], conditions[1:]...)
+	}
+	return criteria
+}
+
+// findCriteria scans every row matching the passed criteria into dest. The table is taken from model, or from the
+// element type of dest when model is nil. The passed relation paths are then preloaded.
+func (db *Database) findCriteria(ctx context.Context, exec executor, model interface{}, dest interface{}, criteria *query.Criteria, preloads []string) error {
+	slice, elemType, err := getSliceAndElemType(dest)
+	if err != nil {
+		return err
+	}
+	if model == nil {
+		model = reflect.New(elemType).Interface()
+	}
+	query, args, err := db.BuildCriteriaQuery(model, criteria)
+	if err != nil {
+		return err
+	}
+	rows, err := exec.QueryContext(ctx, query, args...)
+	if err != nil {
+		return newQueryError(err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return errors.Wrap(err, "Failed to read columns.")
+	}
+	slice.Set(slice.Slice(0, 0))
+	for rows.Next() {
+		elem := reflect.New(elemType)
+		if err := db.scanRow(rows, columns, elem.Interface()); err != nil {
+			return err
+		}
+		if slice.Type().Elem().Kind() == reflect.Ptr {
+			slice.Set(reflect.Append(slice, elem))
+		} else {
+			slice.Set(reflect.Append(slice, elem.Elem()))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return newQueryError(err)
+	}
+	rows.Close()
+	pointers := getStructPointers(slice)
+	if err := afterSelectAll(ctx, pointers); err != nil {
+		return err
+	}
+	return db.preload(ctx, exec, pointers, preloads)
+}
+
+// getSliceAndElemType returns the slice pointed to by dest and the struct type of its elements.
+func getSliceAndElemType(dest interface{}) (reflect.Value, reflect.Type, error) {
+	val := reflect.ValueOf(dest)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Slice {
+		return reflect.Value{}, nil, errors.Errorf("Unable to find into '%T' (expected a pointer to a slice).", dest)
+	}
+	elemType := val.Elem().Type().Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return reflect.Value{}, nil, errors.Errorf("Unable to find into '%T' (expected a slice of structs).", dest)
+	}
+	return val.Elem(), elemType, nil
+}
+
+// checkRowsAffected returns ErrNoRowsAffected when the passed result reports that no rows were affected. Drivers which
+// do not report affected rows are assumed to have succeeded.
+func checkRowsAffected(result sql.Result) error {
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNoRowsAffected
+	}
+	return nil
 }
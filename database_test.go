@@ -2,9 +2,12 @@ package database_test
 
 import (
 	. "github.com/dtucker2/database"
+	"github.com/dtucker2/database/query"
 
+	"context"
 	"database/sql/driver"
 	"fmt"
+	"runtime"
 	"testing"
 	"time"
 
@@ -46,7 +49,7 @@ func TestDatabase_Insert(t *testing.T) {
 		}
 		db, mock, err := sqlmock.New()
 		require.NoError(t, err)
-		mock.ExpectExec(`INSERT INTO objects \(Id,Name,CreatedAt,UpdatedAt\) VALUES \(\?,\?,\?,\?\)`).
+		mock.ExpectExec("INSERT INTO `objects` \\(`Id`,`Name`,`CreatedAt`,`UpdatedAt`\\) VALUES \\(\\?,\\?,\\?,\\?\\)").
 			WithArgs(0, "Test Object", (*time.Time)(nil), (*time.Time)(nil)).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 		require.NoError(t, NewDatabase(db).Insert(&obj))
@@ -58,11 +61,28 @@ func TestDatabase_Insert(t *testing.T) {
 		}
 		db, mock, err := sqlmock.New()
 		require.NoError(t, err)
-		mock.ExpectExec(`INSERT INTO objects \(name,created_at\) VALUES \(\?,\?\)`).
+		mock.ExpectExec("INSERT INTO `objects` \\(`name`,`created_at`\\) VALUES \\(\\?,\\?\\)").
 			WithArgs("Test Object", anyTime{}).
-			WillReturnResult(sqlmock.NewResult(1, 1))
+			WillReturnResult(sqlmock.NewResult(7, 1))
 		require.NoError(t, NewDatabase(db).Insert(&obj))
 		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, 7, obj.Id)
+		assert.NotNil(t, obj.CreatedAt)
+		assert.Nil(t, obj.UpdatedAt)
+	})
+	t.Run("returning", func(t *testing.T) {
+		obj := objectWithTags{
+			Name: "Test Object",
+		}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery(`INSERT INTO "objects" \("name","created_at"\) VALUES \(\$1,\$2\) RETURNING "id"`).
+			WithArgs("Test Object", anyTime{}).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+		require.NoError(t, NewDatabase(db, WithDialect(query.PostgreSQL)).Insert(&obj))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, 7, obj.Id)
+		assert.NotNil(t, obj.CreatedAt)
 	})
 	t.Run("error", func(t *testing.T) {
 		obj := object{
@@ -70,7 +90,7 @@ func TestDatabase_Insert(t *testing.T) {
 		}
 		db, mock, err := sqlmock.New()
 		require.NoError(t, err)
-		mock.ExpectExec(`INSERT INTO objects \(Id,Name,CreatedAt,UpdatedAt\) VALUES \(\?,\?,\?,\?\)`).
+		mock.ExpectExec("INSERT INTO `objects` \\(`Id`,`Name`,`CreatedAt`,`UpdatedAt`\\) VALUES \\(\\?,\\?,\\?,\\?\\)").
 			WithArgs(0, "Test Object", (*time.Time)(nil), (*time.Time)(nil)).
 			WillReturnError(fmt.Errorf("Something terrible happened!"))
 		require.Error(t, NewDatabase(db).Insert(&obj))
@@ -85,8 +105,8 @@ func TestDatabase_Update(t *testing.T) {
 		}
 		db, mock, err := sqlmock.New()
 		require.NoError(t, err)
-		mock.ExpectExec(`UPDATE objects SET Id=\?,Name=\?,CreatedAt=\?,UpdatedAt=\? WHERE Id=\?`).
-			WithArgs(0, "Test Object", (*time.Time)(nil), (*time.Time)(nil), 0).
+		mock.ExpectExec("UPDATE `objects` SET `Name`=\\?,`CreatedAt`=\\?,`UpdatedAt`=\\? WHERE `Id`=\\?").
+			WithArgs("Test Object", (*time.Time)(nil), (*time.Time)(nil), 0).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 		require.NoError(t, NewDatabase(db).Update(&obj))
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -98,7 +118,7 @@ func TestDatabase_Update(t *testing.T) {
 		}
 		db, mock, err := sqlmock.New()
 		require.NoError(t, err)
-		mock.ExpectExec(`UPDATE objects SET name=\?,updated_at=\? WHERE id=\?`).
+		mock.ExpectExec("UPDATE `objects` SET `name`=\\?,`updated_at`=\\? WHERE `id`=\\?").
 			WithArgs("Test Object", anyTime{}, 1).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 		require.NoError(t, NewDatabase(db).Update(&obj))
@@ -111,7 +131,7 @@ func TestDatabase_Update(t *testing.T) {
 		}
 		db, mock, err := sqlmock.New()
 		require.NoError(t, err)
-		mock.ExpectExec(`UPDATE objects SET name=\?,updated_at=\? WHERE id=\?`).
+		mock.ExpectExec("UPDATE `objects` SET `name`=\\?,`updated_at`=\\? WHERE `id`=\\?").
 			WithArgs("Test Object", anyTime{}, 1).
 			WillReturnError(fmt.Errorf("Something terrible happened!"))
 		require.Error(t, NewDatabase(db).Update(&obj))
@@ -126,7 +146,7 @@ func TestDatabase_Delete(t *testing.T) {
 		}
 		db, mock, err := sqlmock.New()
 		require.NoError(t, err)
-		mock.ExpectExec(`DELETE FROM objects WHERE Id=\?`).
+		mock.ExpectExec("DELETE FROM `objects` WHERE `Id`=\\?").
 			WithArgs(1).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 		require.NoError(t, NewDatabase(db).Delete(&obj))
@@ -138,7 +158,7 @@ func TestDatabase_Delete(t *testing.T) {
 		}
 		db, mock, err := sqlmock.New()
 		require.NoError(t, err)
-		mock.ExpectExec(`DELETE FROM objects WHERE id=\?`).
+		mock.ExpectExec("DELETE FROM `objects` WHERE `id`=\\?").
 			WithArgs(1).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 		require.NoError(t, NewDatabase(db).Delete(&obj))
@@ -150,7 +170,7 @@ func TestDatabase_Delete(t *testing.T) {
 		}
 		db, mock, err := sqlmock.New()
 		require.NoError(t, err)
-		mock.ExpectExec(`DELETE FROM objects WHERE id=\?`).
+		mock.ExpectExec("DELETE FROM `objects` WHERE `id`=\\?").
 			WithArgs(1).
 			WillReturnError(fmt.Errorf("Something terrible happened!"))
 		require.Error(t, NewDatabase(db).Delete(&obj))
@@ -165,7 +185,7 @@ func TestDatabase_Select(t *testing.T) {
 		}
 		db, mock, err := sqlmock.New()
 		require.NoError(t, err)
-		mock.ExpectQuery(`SELECT Id,Name,CreatedAt,UpdatedAt FROM objects WHERE Id=\?`).
+		mock.ExpectQuery("SELECT `Id`,`Name`,`CreatedAt`,`UpdatedAt` FROM `objects` WHERE `Id`=\\?").
 			WithArgs(1).
 			WillReturnRows(sqlmock.NewRows([]string{"Id", "Name", "CreatedAt", "UpdatedAt"}).
 				AddRow(1, "Test Object", (*time.Time)(nil), (*time.Time)(nil)))
@@ -179,7 +199,7 @@ func TestDatabase_Select(t *testing.T) {
 		}
 		db, mock, err := sqlmock.New()
 		require.NoError(t, err)
-		mock.ExpectQuery(`SELECT id,name,created_at,updated_at FROM objects WHERE id=\?`).
+		mock.ExpectQuery("SELECT `id`,`name`,`created_at`,`updated_at` FROM `objects` WHERE `id`=\\?").
 			WithArgs(1).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at", "updated_at"}).
 				AddRow(1, "Test Object", (*time.Time)(nil), (*time.Time)(nil)))
@@ -193,10 +213,529 @@ func TestDatabase_Select(t *testing.T) {
 		}
 		db, mock, err := sqlmock.New()
 		require.NoError(t, err)
-		mock.ExpectQuery(`SELECT id,name,created_at,updated_at FROM objects WHERE id=\?`).
+		mock.ExpectQuery("SELECT `id`,`name`,`created_at`,`updated_at` FROM `objects` WHERE `id`=\\?").
 			WithArgs(1).
 			WillReturnError(fmt.Errorf("Something terrible happened!"))
 		require.Error(t, NewDatabase(db).Select(&obj))
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
+
+func TestDatabase_Context(t *testing.T) {
+	t.Run("insert", func(t *testing.T) {
+		obj := objectWithTags{
+			Name: "Test Object",
+		}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectExec("INSERT INTO `objects` \\(`name`,`created_at`\\) VALUES \\(\\?,\\?\\)").
+			WithArgs("Test Object", anyTime{}).
+			WillDelayFor(time.Second).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		require.Error(t, NewDatabase(db).InsertContext(ctx, &obj))
+	})
+	t.Run("update", func(t *testing.T) {
+		obj := objectWithTags{
+			Id:   1,
+			Name: "Test Object",
+		}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectExec("UPDATE `objects` SET `name`=\\?,`updated_at`=\\? WHERE `id`=\\?").
+			WithArgs("Test Object", anyTime{}, 1).
+			WillDelayFor(time.Second).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		require.Error(t, NewDatabase(db).UpdateContext(ctx, &obj))
+	})
+	t.Run("delete", func(t *testing.T) {
+		obj := objectWithTags{
+			Id: 1,
+		}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectExec("DELETE FROM `objects` WHERE `id`=\\?").
+			WithArgs(1).
+			WillDelayFor(time.Second).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		require.Error(t, NewDatabase(db).DeleteContext(ctx, &obj))
+	})
+	t.Run("select", func(t *testing.T) {
+		obj := objectWithTags{
+			Id: 1,
+		}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("SELECT `id`,`name`,`created_at`,`updated_at` FROM `objects` WHERE `id`=\\?").
+			WithArgs(1).
+			WillDelayFor(time.Second).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at", "updated_at"}).
+				AddRow(1, "Test Object", (*time.Time)(nil), (*time.Time)(nil)))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		require.Error(t, NewDatabase(db).SelectContext(ctx, &obj))
+	})
+}
+
+func TestDatabase_Find(t *testing.T) {
+	t.Run("structs", func(t *testing.T) {
+		objs := []objectWithTags{}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("SELECT `id`,`name`,`created_at`,`updated_at` FROM `objects` WHERE id > \\?").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at", "updated_at"}).
+				AddRow(2, "Test Object 2", (*time.Time)(nil), (*time.Time)(nil)).
+				AddRow(3, "Test Object 3", (*time.Time)(nil), (*time.Time)(nil)))
+		require.NoError(t, NewDatabase(db).Find(&objs, "id > ?", 1))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, []objectWithTags{{Id: 2, Name: "Test Object 2"}, {Id: 3, Name: "Test Object 3"}}, objs)
+	})
+	t.Run("pointers", func(t *testing.T) {
+		objs := []*objectWithTags{{Id: 9}}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("SELECT `id`,`name`,`created_at`,`updated_at` FROM `objects`").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at", "updated_at"}).
+				AddRow(2, "Test Object 2", (*time.Time)(nil), (*time.Time)(nil)))
+		require.NoError(t, NewDatabase(db).Find(&objs))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, []*objectWithTags{{Id: 2, Name: "Test Object 2"}}, objs)
+	})
+	t.Run("error", func(t *testing.T) {
+		objs := []objectWithTags{}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("SELECT `id`,`name`,`created_at`,`updated_at` FROM `objects` WHERE id > \\?").
+			WithArgs(1).
+			WillReturnError(fmt.Errorf("Something terrible happened!"))
+		require.Error(t, NewDatabase(db).Find(&objs, "id > ?", 1))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("invalid destination", func(t *testing.T) {
+		db, _, err := sqlmock.New()
+		require.NoError(t, err)
+		require.Error(t, NewDatabase(db).Find(&objectWithTags{}))
+		require.Error(t, NewDatabase(db).Find(&[]int{}))
+		require.Error(t, NewDatabase(db).Find(&[]objectWithTags{}, 1))
+	})
+}
+
+func TestDatabase_Upsert(t *testing.T) {
+	t.Run("basic", func(t *testing.T) {
+		obj := objectWithTags{
+			Id:   7,
+			Name: "Test Object",
+		}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectExec("INSERT INTO `objects` \\(`id`,`name`,`created_at`\\) VALUES \\(\\?,\\?,\\?\\) "+
+			"ON DUPLICATE KEY UPDATE `name`=VALUES\\(`name`\\),`updated_at`=\\?,`id`=LAST_INSERT_ID\\(`id`\\)").
+			WithArgs(7, "Test Object", anyTime{}, anyTime{}).
+			WillReturnResult(sqlmock.NewResult(7, 2))
+		require.NoError(t, NewDatabase(db).Upsert(&obj))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, 7, obj.Id)
+	})
+	t.Run("returning", func(t *testing.T) {
+		obj := objectWithTags{
+			Id:   7,
+			Name: "Test Object",
+		}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery(`INSERT INTO "objects" \("id","name","created_at"\) VALUES \(\$1,\$2,\$3\) `+
+			`ON CONFLICT \("id"\) DO UPDATE SET "name"=EXCLUDED."name","updated_at"=\$4 RETURNING "id"`).
+			WithArgs(7, "Test Object", anyTime{}, anyTime{}).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+		require.NoError(t, NewDatabase(db, WithDialect(query.PostgreSQL)).Upsert(&obj, "name"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, 7, obj.Id)
+	})
+	t.Run("no unique field", func(t *testing.T) {
+		obj := objectWithTags{
+			Name: "Test Object",
+		}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		// A generated id never matches an existing row, so the row would be duplicated.
+		require.Error(t, NewDatabase(db).Upsert(&obj))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("error", func(t *testing.T) {
+		obj := objectWithTags{
+			Id:   7,
+			Name: "Test Object",
+		}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectExec("INSERT INTO `objects`").
+			WillReturnError(fmt.Errorf("Something terrible happened!"))
+		require.Error(t, NewDatabase(db).Upsert(&obj))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDatabase_InsertMany(t *testing.T) {
+	t.Run("basic", func(t *testing.T) {
+		objs := []objectWithTags{{Name: "First"}, {Name: "Second"}}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectExec("INSERT INTO `objects` \\(`name`,`created_at`\\) VALUES \\(\\?,\\?\\),\\(\\?,\\?\\)").
+			WithArgs("First", anyTime{}, "Second", anyTime{}).
+			WillReturnResult(sqlmock.NewResult(7, 2))
+		affected, err := NewDatabase(db).InsertMany(objs)
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, int64(2), affected)
+		assert.Equal(t, 7, objs[0].Id)
+		assert.Equal(t, 8, objs[1].Id)
+		assert.NotNil(t, objs[0].CreatedAt)
+		assert.NotNil(t, objs[1].CreatedAt)
+	})
+	t.Run("returning", func(t *testing.T) {
+		objs := []*objectWithTags{{Name: "First"}, {Name: "Second"}}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery(`INSERT INTO "objects" \("name","created_at"\) VALUES \(\$1,\$2\),\(\$3,\$4\) RETURNING "id"`).
+			WithArgs("First", anyTime{}, "Second", anyTime{}).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3).AddRow(9))
+		affected, err := NewDatabase(db, WithDialect(query.PostgreSQL)).InsertMany(objs)
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, int64(2), affected)
+		assert.Equal(t, 3, objs[0].Id)
+		assert.Equal(t, 9, objs[1].Id)
+	})
+	t.Run("returning out of order", func(t *testing.T) {
+		objs := []*objectWithTags{{Name: "First"}, {Name: "Second"}, {Name: "Third"}}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		// SQLite does not guarantee that RETURNING rows are in insertion order.
+		mock.ExpectQuery(`INSERT INTO "objects" \("name","created_at"\) VALUES \(\?,\?\),\(\?,\?\),\(\?,\?\) RETURNING "id"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5).AddRow(3).AddRow(4))
+		affected, err := NewDatabase(db, WithDialect(query.SQLite)).InsertMany(objs)
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, int64(3), affected)
+		assert.Equal(t, 3, objs[0].Id)
+		assert.Equal(t, 4, objs[1].Id)
+		assert.Equal(t, 5, objs[2].Id)
+	})
+	t.Run("batches", func(t *testing.T) {
+		objs := []objectWithTags{{Name: "First"}, {Name: "Second"}}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `objects` \\(`name`,`created_at`\\) VALUES \\(\\?,\\?\\)$").
+			WithArgs("First", anyTime{}).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO `objects` \\(`name`,`created_at`\\) VALUES \\(\\?,\\?\\)$").
+			WithArgs("Second", anyTime{}).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+		affected, err := NewDatabase(db, WithMaxQuerySize(100)).InsertMany(&objs)
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, int64(2), affected)
+		assert.Equal(t, 2, objs[1].Id)
+	})
+	t.Run("error", func(t *testing.T) {
+		objs := []objectWithTags{{Name: "First"}, {Name: "Second"}}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `objects`").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO `objects`").
+			WillReturnError(fmt.Errorf("Something terrible happened!"))
+		mock.ExpectRollback()
+		affected, err := NewDatabase(db, WithMaxQuerySize(100)).InsertMany(objs)
+		require.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, int64(0), affected)
+	})
+}
+
+type objectWithSoftDelete struct {
+	Id        int        `name:"id" key:"true"`
+	Name      string     `name:"name"`
+	DeletedAt *time.Time `name:"deleted_at" type:"deleted_at"`
+}
+
+func (obj *objectWithSoftDelete) GetTableName() string {
+	return "objects"
+}
+
+func TestDatabase_SoftDelete(t *testing.T) {
+	t.Run("delete", func(t *testing.T) {
+		obj := objectWithSoftDelete{Id: 1}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectExec("UPDATE `objects` SET `deleted_at`=\\? WHERE `id`=\\? AND `deleted_at` IS NULL").
+			WithArgs(anyTime{}, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		require.NoError(t, NewDatabase(db).Delete(&obj))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.NotNil(t, obj.DeletedAt)
+	})
+	t.Run("unscoped delete", func(t *testing.T) {
+		obj := objectWithSoftDelete{Id: 1}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectExec("DELETE FROM `objects` WHERE `id`=\\?").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM `objects` WHERE `id`=\\?").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		database := NewDatabase(db)
+		require.NoError(t, database.Unscoped().Delete(&obj))
+		require.NoError(t, database.ForceDelete(&obj))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Nil(t, obj.DeletedAt)
+	})
+	t.Run("restore", func(t *testing.T) {
+		now := time.Now()
+		obj := objectWithSoftDelete{Id: 1, DeletedAt: &now}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectExec("UPDATE `objects` SET `deleted_at`=NULL WHERE `id`=\\?").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		require.NoError(t, NewDatabase(db).Restore(&obj))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Nil(t, obj.DeletedAt)
+	})
+	t.Run("find", func(t *testing.T) {
+		objs := []objectWithSoftDelete{}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("SELECT `id`,`name`,`deleted_at` FROM `objects` WHERE `deleted_at` IS NULL$").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "deleted_at"}).AddRow(1, "Test Object", nil))
+		mock.ExpectQuery("SELECT `id`,`name`,`deleted_at` FROM `objects` WHERE `name`=\\?$").
+			WithArgs("Test Object").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "deleted_at"}).AddRow(1, "Test Object", nil))
+		database := NewDatabase(db)
+		require.NoError(t, database.Find(&objs))
+		require.NoError(t, database.Model(&objectWithSoftDelete{}).WithTrashed().Where(&objectWithSoftDelete{Name: "Test Object"}).Find(&objs))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Len(t, objs, 1)
+	})
+	t.Run("count", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM `objects` WHERE \\(name = \\?\\) AND `deleted_at` IS NULL").
+			WithArgs("Test Object").
+			WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(3))
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM `objects`$").
+			WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(5))
+		database := NewDatabase(db)
+		count, err := database.Count(&objectWithSoftDelete{}, "name = ?", "Test Object")
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), count)
+		count, err = database.Unscoped().Model(&objectWithSoftDelete{}).Count()
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), count)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+type objectWithVersion struct {
+	Id      int    `name:"id" key:"true"`
+	Name    string `name:"name"`
+	Version uint   `name:"version" type:"version"`
+}
+
+func (obj *objectWithVersion) GetTableName() string {
+	return "objects"
+}
+
+func TestDatabase_Version(t *testing.T) {
+	t.Run("update", func(t *testing.T) {
+		obj := objectWithVersion{Id: 1, Name: "Test Object", Version: 3}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectExec("UPDATE `objects` SET `name`=\\?,`version`=`version`\\+1 WHERE `id`=\\? AND `version`=\\?").
+			WithArgs("Test Object", 1, uint(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		require.NoError(t, NewDatabase(db).Update(&obj))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, uint(4), obj.Version)
+	})
+	t.Run("stale", func(t *testing.T) {
+		obj := objectWithVersion{Id: 1, Name: "Test Object", Version: 3}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectExec("UPDATE `objects`").
+			WithArgs("Test Object", 1, uint(3)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		err = NewDatabase(db).Update(&obj)
+		assert.Equal(t, ErrStaleObject, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, uint(3), obj.Version)
+	})
+}
+
+func TestDatabase_Track(t *testing.T) {
+	t.Run("changed", func(t *testing.T) {
+		obj := objectWithVersion{Id: 1, Name: "Test Object", Version: 3}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectExec("UPDATE `objects` SET `name`=\\?,`version`=`version`\\+1 WHERE `id`=\\? AND `version`=\\?").
+			WithArgs("Renamed Object", 1, uint(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		database := NewDatabase(db)
+		require.NoError(t, database.Track(&obj))
+		obj.Name = "Renamed Object"
+		require.NoError(t, database.Update(&obj))
+		// The snapshot is refreshed by the update, so a second update has nothing to write.
+		require.NoError(t, database.Update(&obj))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("select", func(t *testing.T) {
+		obj := objectWithTags{Id: 1}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("SELECT .* FROM `objects` WHERE `id`=\\?").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at", "updated_at"}).
+				AddRow(1, "Test Object", nil, nil))
+		mock.ExpectExec("UPDATE `objects` SET `name`=\\?,`updated_at`=\\? WHERE `id`=\\?").
+			WithArgs("Renamed Object", anyTime{}, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		database := NewDatabase(db)
+		require.NoError(t, database.Track(&obj))
+		require.NoError(t, database.Select(&obj))
+		require.NoError(t, database.Update(&obj))
+		obj.Name = "Renamed Object"
+		require.NoError(t, database.Update(&obj))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("transaction", func(t *testing.T) {
+		obj := objectWithTags{Id: 1, Name: "Test Object"}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE `objects` SET `name`=\\?,`updated_at`=\\? WHERE `id`=\\?").
+			WithArgs("Renamed Object", anyTime{}, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectRollback()
+		// The snapshot is restored by the rollback, so the change is written again.
+		mock.ExpectExec("UPDATE `objects` SET `name`=\\?,`updated_at`=\\? WHERE `id`=\\?").
+			WithArgs("Renamed Object", anyTime{}, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE `objects` SET `name`=\\?,`updated_at`=\\? WHERE `id`=\\?").
+			WithArgs("Final Object", anyTime{}, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		database := NewDatabase(db)
+		require.NoError(t, database.Track(&obj))
+		defer database.Untrack(&obj)
+		rollbackErr := fmt.Errorf("Rolled back.")
+		err = database.WithTransaction(context.Background(), func(tx *Tx) error {
+			obj.Name = "Renamed Object"
+			if err := tx.Update(&obj); err != nil {
+				return err
+			}
+			return rollbackErr
+		})
+		require.Equal(t, rollbackErr, err)
+		require.NoError(t, database.Update(&obj))
+		err = database.WithTransaction(context.Background(), func(tx *Tx) error {
+			obj.Name = "Final Object"
+			return tx.Update(&obj)
+		})
+		require.NoError(t, err)
+		// The snapshot refreshed by the committed transaction is kept, so there is nothing left to write.
+		require.NoError(t, database.Update(&obj))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("untrack", func(t *testing.T) {
+		obj := objectWithTags{Id: 1, Name: "Test Object"}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectExec("UPDATE `objects` SET `name`=\\?,`updated_at`=\\? WHERE `id`=\\?").
+			WithArgs("Test Object", anyTime{}, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		database := NewDatabase(db)
+		require.NoError(t, database.Track(&obj))
+		database.Untrack(&obj)
+		require.NoError(t, database.Update(&obj))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("untrack releases", func(t *testing.T) {
+		db, _, err := sqlmock.New()
+		require.NoError(t, err)
+		database := NewDatabase(db)
+		collected := make(chan struct{})
+		obj := &objectWithTags{Id: 1, Name: "Test Object"}
+		runtime.SetFinalizer(obj, func(*objectWithTags) { close(collected) })
+		require.NoError(t, database.Track(obj))
+		database.Untrack(obj)
+		obj = nil
+		released := false
+		for i := 0; i < 100 && !released; i++ {
+			runtime.GC()
+			select {
+			case <-collected:
+				released = true
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+		assert.True(t, released, "Untracked struct was not garbage collected.")
+		runtime.KeepAlive(database)
+	})
+}
+
+func TestDatabase_UpdateColumns(t *testing.T) {
+	t.Run("columns", func(t *testing.T) {
+		obj := objectWithTags{Id: 1, Name: "Test Object"}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectExec("UPDATE `objects` SET `name`=\\?,`updated_at`=\\? WHERE `id`=\\?").
+			WithArgs("Test Object", anyTime{}, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		require.NoError(t, NewDatabase(db).UpdateColumns(&obj, "name"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("map", func(t *testing.T) {
+		obj := objectWithVersion{Id: 1, Name: "Test Object", Version: 3}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectExec("UPDATE `objects` SET `name`=\\?,`version`=`version`\\+1 WHERE `id`=\\? AND `version`=\\?").
+			WithArgs("Renamed Object", 1, uint(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		require.NoError(t, NewDatabase(db).UpdateMap(&obj, map[string]interface{}{"name": "Renamed Object"}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, "Test Object", obj.Name)
+		assert.Equal(t, uint(4), obj.Version)
+	})
+	t.Run("tracked", func(t *testing.T) {
+		obj := objectWithSoftDelete{Id: 1, Name: "Test Object"}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectExec("UPDATE `objects` SET `name`=\\? WHERE `id`=\\?").
+			WithArgs("Renamed Object", 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		database := NewDatabase(db)
+		require.NoError(t, database.Track(&obj))
+		obj.Name = "Renamed Object"
+		require.NoError(t, database.UpdateColumns(&obj, "name"))
+		require.NoError(t, database.Update(&obj))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("unknown column", func(t *testing.T) {
+		obj := objectWithTags{Id: 1}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		require.Error(t, NewDatabase(db).UpdateColumns(&obj, "missing"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,131 @@
+package database
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+
+	"github.com/dtucker2/database/query"
+)
+
+var (
+	// ErrNotFound is returned by Select when no row matches the struct's primary key.
+	ErrNotFound = errors.New("Record not found.")
+	// ErrNoRowsAffected is returned by Update and Delete when no row matches the struct's primary key.
+	// As MySQL does not count rows whose values are unchanged by an update (unless the client sets CLIENT_FOUND_ROWS),
+	// Update checks that the row exists before returning it on MySQL.
+	ErrNoRowsAffected = errors.New("No rows affected.")
+	// ErrNoPrimaryKey is returned when a struct has no primary key field.
+	ErrNoPrimaryKey = query.ErrNoPrimaryKey
+	// ErrDuplicateKey is returned when a query violates a primary key or unique constraint.
+	ErrDuplicateKey = errors.New("Duplicate key.")
+	// ErrForeignKeyViolation is returned when a query violates a foreign key constraint.
+	ErrForeignKeyViolation = errors.New("Foreign key violation.")
+	// ErrStaleObject is returned by Update when a struct with a version field no longer matches the row's version,
+	// meaning the row was updated (or deleted) since the struct was read.
+	ErrStaleObject = errors.New("Stale object.")
+)
+
+// QueryError is returned when executing a query fails. It wraps the underlying error along with, when the failure is
+// recognised, one of the sentinel errors (e.g. ErrDuplicateKey) so that both can be matched using errors.Is.
+type QueryError struct {
+	// Err is the underlying error returned by the driver.
+	Err error
+	// Kind is the sentinel error describing the failure, or nil when the failure was not recognised.
+	Kind error
+}
+
+// newQueryError wraps the passed error, classifying it using ClassifyError.
+func newQueryError(err error) error {
+	return &QueryError{
+		Err:  err,
+		Kind: ClassifyError(err),
+	}
+}
+
+func (err *QueryError) Error() string {
+	return "Failed to execute query.: " + err.Err.Error()
+}
+
+// Is reports whether the passed error is the sentinel error describing the failure, for use by errors.Is.
+func (err *QueryError) Is(target error) bool {
+	return err.Kind != nil && err.Kind == target
+}
+
+// Unwrap returns the underlying error for use by errors.Is and errors.As.
+func (err *QueryError) Unwrap() error {
+	return err.Err
+}
+
+// Cause returns the underlying error for use by errors.Cause.
+func (err *QueryError) Cause() error {
+	return err.Err
+}
+
+// mysqlErrorNumbers maps MySQL error numbers to sentinel errors.
+var mysqlErrorNumbers = map[uint64]error{
+	1062: ErrDuplicateKey,
+	1451: ErrForeignKeyViolation,
+	1452: ErrForeignKeyViolation,
+}
+
+// sqlStates maps PostgreSQL SQLSTATE codes to sentinel errors.
+var sqlStates = map[string]error{
+	"23505": ErrDuplicateKey,
+	"23503": ErrForeignKeyViolation,
+}
+
+// ClassifyError maps a driver error to ErrDuplicateKey or ErrForeignKeyViolation, returning nil when the error is not
+// recognised. MySQL errors are recognised by their 'Number' field (as in github.com/go-sql-driver/mysql) and
+// PostgreSQL errors by their SQLSTATE, from a 'SQLState' method or 'Code' field (as in github.com/lib/pq and pgx).
+// Drivers are matched structurally so that none of them need to be imported.
+func ClassifyError(err error) error {
+	for err != nil {
+		if kind := classifyDriverError(err); kind != nil {
+			return kind
+		}
+		switch wrapper := err.(type) {
+		case interface{ Unwrap() error }:
+			err = wrapper.Unwrap()
+		case interface{ Unwrap() []error }:
+			// Errors joining several errors (e.g. using errors.Join) are recognised by the first recognised error.
+			for _, err := range wrapper.Unwrap() {
+				if kind := ClassifyError(err); kind != nil {
+					return kind
+				}
+			}
+			return nil
+		case interface{ Cause() error }:
+			err = wrapper.Cause()
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func classifyDriverError(err error) error {
+	if stater, ok := err.(interface{ SQLState() string }); ok {
+		return sqlStates[stater.SQLState()]
+	}
+	val := reflect.ValueOf(err)
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return nil
+	}
+	if number := val.FieldByName("Number"); number.IsValid() {
+		switch number.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return mysqlErrorNumbers[number.Uint()]
+		}
+	}
+	if code := val.FieldByName("Code"); code.IsValid() && code.Kind() == reflect.String {
+		return sqlStates[code.String()]
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package database_test
+
+import (
+	. "github.com/dtucker2/database"
+	"github.com/dtucker2/database/query"
+
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mysqlError mimics github.com/go-sql-driver/mysql.MySQLError.
+type mysqlError struct {
+	Number  uint16
+	Message string
+}
+
+func (err *mysqlError) Error() string {
+	return fmt.Sprintf("Error %d: %s", err.Number, err.Message)
+}
+
+// postgresError mimics github.com/lib/pq.Error.
+type postgresError struct {
+	Code    string
+	Message string
+}
+
+func (err *postgresError) Error() string {
+	return "pq: " + err.Message
+}
+
+func (err *postgresError) SQLState() string {
+	return err.Code
+}
+
+// pgxError mimics github.com/jackc/pgconn.PgError, without the SQLState method.
+type pgxError struct {
+	Code string
+}
+
+func (err pgxError) Error() string {
+	return "ERROR: " + err.Code
+}
+
+type objectWithNoKey struct {
+	Name string `name:"name"`
+}
+
+// joinedError mimics the errors returned by errors.Join, which requires Go 1.20.
+type joinedError []error
+
+func (err joinedError) Error() string {
+	return fmt.Sprint([]error(err))
+}
+
+func (err joinedError) Unwrap() []error {
+	return err
+}
+
+func TestClassifyError(t *testing.T) {
+	assert.Equal(t, ErrDuplicateKey, ClassifyError(&mysqlError{Number: 1062}))
+	assert.Equal(t, ErrForeignKeyViolation, ClassifyError(&mysqlError{Number: 1451}))
+	assert.Equal(t, ErrForeignKeyViolation, ClassifyError(&mysqlError{Number: 1452}))
+	assert.Nil(t, ClassifyError(&mysqlError{Number: 1064}))
+	assert.Equal(t, ErrDuplicateKey, ClassifyError(&postgresError{Code: "23505"}))
+	assert.Equal(t, ErrForeignKeyViolation, ClassifyError(&postgresError{Code: "23503"}))
+	assert.Nil(t, ClassifyError(&postgresError{Code: "42601"}))
+	assert.Equal(t, ErrDuplicateKey, ClassifyError(pgxError{Code: "23505"}))
+	assert.Equal(t, ErrDuplicateKey, ClassifyError(fmt.Errorf("wrapped: %w", &mysqlError{Number: 1062})))
+	assert.Equal(t, ErrForeignKeyViolation, ClassifyError(joinedError{fmt.Errorf("Rollback failed."), &postgresError{Code: "23503"}}))
+	assert.Nil(t, ClassifyError(joinedError{fmt.Errorf("Rollback failed.")}))
+	assert.Nil(t, ClassifyError(fmt.Errorf("Something terrible happened!")))
+	assert.Nil(t, ClassifyError(nil))
+}
+
+func TestDatabase_Errors(t *testing.T) {
+	t.Run("not found", func(t *testing.T) {
+		obj := objectWithTags{
+			Id: 1,
+		}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("SELECT `id`,`name`,`created_at`,`updated_at` FROM `objects` WHERE `id`=\\?").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at", "updated_at"}))
+		err = NewDatabase(db).Select(&obj)
+		assert.True(t, errors.Is(err, ErrNotFound))
+		assert.True(t, errors.Is(err, sql.ErrNoRows))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("no rows affected", func(t *testing.T) {
+		obj := objectWithTags{
+			Id:   1,
+			Name: "Test Object",
+		}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectExec("UPDATE `objects` SET `name`=\\?,`updated_at`=\\? WHERE `id`=\\?").
+			WithArgs("Test Object", anyTime{}, 1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT 1 FROM `objects` WHERE `id`=\\?").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}))
+		mock.ExpectExec("DELETE FROM `objects` WHERE `id`=\\?").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		assert.True(t, errors.Is(NewDatabase(db).Update(&obj), ErrNoRowsAffected))
+		assert.True(t, errors.Is(NewDatabase(db).Delete(&obj), ErrNoRowsAffected))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("no rows changed", func(t *testing.T) {
+		obj := objectWithTags{
+			Id:   1,
+			Name: "Test Object",
+		}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		// MySQL reports no rows affected when the row already holds the updated values.
+		mock.ExpectExec("UPDATE `objects` SET `name`=\\?,`updated_at`=\\? WHERE `id`=\\?").
+			WithArgs("Test Object", anyTime{}, 1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT 1 FROM `objects` WHERE `id`=\\?").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+		// Other databases report the rows matched, so the row is not looked up.
+		mock.ExpectExec(`UPDATE "objects" SET "name"=\$1,"updated_at"=\$2 WHERE "id"=\$3`).
+			WithArgs("Test Object", anyTime{}, 1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		assert.NoError(t, NewDatabase(db).Update(&obj))
+		assert.True(t, errors.Is(NewDatabase(db, WithDialect(query.PostgreSQL)).Update(&obj), ErrNoRowsAffected))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("no primary key", func(t *testing.T) {
+		db, _, err := sqlmock.New()
+		require.NoError(t, err)
+		assert.True(t, errors.Is(NewDatabase(db).Select(&objectWithNoKey{}), ErrNoPrimaryKey))
+		assert.True(t, errors.Is(NewDatabase(db).Update(&objectWithNoKey{}), ErrNoPrimaryKey))
+		assert.True(t, errors.Is(NewDatabase(db).Delete(&objectWithNoKey{}), ErrNoPrimaryKey))
+	})
+	t.Run("duplicate key", func(t *testing.T) {
+		obj := objectWithTags{
+			Name: "Test Object",
+		}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectExec("INSERT INTO `objects` \\(`name`,`created_at`\\) VALUES \\(\\?,\\?\\)").
+			WithArgs("Test Object", anyTime{}).
+			WillReturnError(&mysqlError{Number: 1062, Message: "Duplicate entry"})
+		err = NewDatabase(db).Insert(&obj)
+		assert.True(t, errors.Is(err, ErrDuplicateKey))
+		var driverErr *mysqlError
+		if assert.True(t, errors.As(err, &driverErr)) {
+			assert.Equal(t, uint16(1062), driverErr.Number)
+		}
+		var queryErr *QueryError
+		if assert.True(t, errors.As(err, &queryErr)) {
+			assert.Equal(t, ErrDuplicateKey, queryErr.Kind)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("foreign key violation", func(t *testing.T) {
+		obj := objectWithTags{
+			Id: 1,
+		}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectExec("DELETE FROM `objects` WHERE `id`=\\?").
+			WithArgs(1).
+			WillReturnError(&postgresError{Code: "23503", Message: "violates foreign key constraint"})
+		err = NewDatabase(db).Delete(&obj)
+		assert.True(t, errors.Is(err, ErrForeignKeyViolation))
+		assert.False(t, errors.Is(err, ErrDuplicateKey))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"reflect"
+)
+
+// BeforeInserter is implemented by structs which need to run code before being inserted, e.g. to normalise their
+// fields. Returning an error aborts the insert. It is called by Insert, InsertMany and Upsert.
+type BeforeInserter interface {
+	BeforeInsert(ctx context.Context) error
+}
+
+// AfterInserter is implemented by structs which need to run code after being inserted, e.g. to emit an event. The
+// generated auto-increment id has been written back into the struct when it is called.
+type AfterInserter interface {
+	AfterInsert(ctx context.Context) error
+}
+
+// BeforeUpdater is implemented by structs which need to run code before being updated. Returning an error aborts the
+// update. It is called by Update, UpdateColumns and UpdateMap.
+type BeforeUpdater interface {
+	BeforeUpdate(ctx context.Context) error
+}
+
+// AfterUpdater is implemented by structs which need to run code after being updated.
+type AfterUpdater interface {
+	AfterUpdate(ctx context.Context) error
+}
+
+// BeforeDeleter is implemented by structs which need to run code before being deleted. Returning an error aborts the
+// delete. It is called by Delete and ForceDelete.
+type BeforeDeleter interface {
+	BeforeDelete(ctx context.Context) error
+}
+
+// AfterDeleter is implemented by structs which need to run code after being deleted.
+type AfterDeleter interface {
+	AfterDelete(ctx context.Context) error
+}
+
+// AfterSelecter is implemented by structs which need to run code after being scanned from a row by Select or Find,
+// e.g. to populate computed fields.
+type AfterSelecter interface {
+	AfterSelect(ctx context.Context) error
+}
+
+// Hooks run within the transaction of the operation calling them, if any, and their errors are returned unwrapped.
+// The transaction is returned by TxFromContext from the context passed to the hook. Note that the row has already been
+// written when an After hook returns an error outside of a transaction.
+
+func beforeInsert(ctx context.Context, object interface{}) error {
+	if hook, ok := object.(BeforeInserter); ok {
+		return hook.BeforeInsert(ctx)
+	}
+	return nil
+}
+
+func afterInsert(ctx context.Context, object interface{}) error {
+	if hook, ok := object.(AfterInserter); ok {
+		return hook.AfterInsert(ctx)
+	}
+	return nil
+}
+
+func beforeUpdate(ctx context.Context, object interface{}) error {
+	if hook, ok := object.(BeforeUpdater); ok {
+		return hook.BeforeUpdate(ctx)
+	}
+	return nil
+}
+
+func afterUpdate(ctx context.Context, object interface{}) error {
+	if hook, ok := object.(AfterUpdater); ok {
+		return hook.AfterUpdate(ctx)
+	}
+	return nil
+}
+
+func beforeDelete(ctx context.Context, object interface{}) error {
+	if hook, ok := object.(BeforeDeleter); ok {
+		return hook.BeforeDelete(ctx)
+	}
+	return nil
+}
+
+func afterDelete(ctx context.Context, object interface{}) error {
+	if hook, ok := object.(AfterDeleter); ok {
+		return hook.AfterDelete(ctx)
+	}
+	return nil
+}
+
+func afterSelect(ctx context.Context, object interface{}) error {
+	if hook, ok := object.(AfterSelecter); ok {
+		return hook.AfterSelect(ctx)
+	}
+	return nil
+}
+
+// afterSelectAll calls afterSelect on each element of the passed slice of struct pointers. It is called once the rows
+// have been closed, so that hooks are able to run queries of their own.
+func afterSelectAll(ctx context.Context, pointers reflect.Value) error {
+	for i := 0; i < pointers.Len(); i++ {
+		if err := afterSelect(ctx, pointers.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package database_test
+
+import (
+	. "github.com/dtucker2/database"
+
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type objectWithHooks struct {
+	Id    int    `name:"id" type:"auto-increment" key:"true"`
+	Name  string `name:"name"`
+	calls []string
+	err   error
+}
+
+func (obj *objectWithHooks) GetTableName() string {
+	return "objects"
+}
+
+func (obj *objectWithHooks) record(hook string) error {
+	obj.calls = append(obj.calls, hook)
+	return obj.err
+}
+
+func (obj *objectWithHooks) BeforeInsert(ctx context.Context) error {
+	obj.Name = strings.TrimSpace(obj.Name)
+	return obj.record("BeforeInsert")
+}
+
+func (obj *objectWithHooks) AfterInsert(ctx context.Context) error {
+	return obj.record(fmt.Sprintf("AfterInsert %d", obj.Id))
+}
+
+func (obj *objectWithHooks) BeforeUpdate(ctx context.Context) error {
+	return obj.record("BeforeUpdate")
+}
+
+func (obj *objectWithHooks) AfterUpdate(ctx context.Context) error {
+	return obj.record("AfterUpdate")
+}
+
+func (obj *objectWithHooks) BeforeDelete(ctx context.Context) error {
+	return obj.record("BeforeDelete")
+}
+
+func (obj *objectWithHooks) AfterDelete(ctx context.Context) error {
+	return obj.record("AfterDelete")
+}
+
+func (obj *objectWithHooks) AfterSelect(ctx context.Context) error {
+	return obj.record("AfterSelect " + obj.Name)
+}
+
+type objectWithAuditHook struct {
+	Id   int    `name:"id" type:"auto-increment" key:"true"`
+	Name string `name:"name"`
+	err  error
+}
+
+func (obj *objectWithAuditHook) GetTableName() string {
+	return "objects"
+}
+
+func (obj *objectWithAuditHook) BeforeInsert(ctx context.Context) error {
+	tx, ok := TxFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("Not within a transaction.")
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO audit (name) VALUES (?)", obj.Name); err != nil {
+		return err
+	}
+	return obj.err
+}
+
+// selectLog records the order in which objectWithSelectAuditHook rows are scanned and their hooks are called.
+var selectLog []string
+
+type loggedName string
+
+func (name *loggedName) Scan(value interface{}) error {
+	*name = loggedName(fmt.Sprintf("%s", value))
+	selectLog = append(selectLog, "Scan "+string(*name))
+	return nil
+}
+
+type objectWithSelectAuditHook struct {
+	Id   int        `name:"id" key:"true"`
+	Name loggedName `name:"name"`
+}
+
+func (obj *objectWithSelectAuditHook) GetTableName() string {
+	return "objects"
+}
+
+func (obj *objectWithSelectAuditHook) AfterSelect(ctx context.Context) error {
+	tx, ok := TxFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("Not within a transaction.")
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO audit (name) VALUES (?)", string(obj.Name)); err != nil {
+		return err
+	}
+	selectLog = append(selectLog, "AfterSelect "+string(obj.Name))
+	return nil
+}
+
+func TestDatabase_Hooks(t *testing.T) {
+	t.Run("insert", func(t *testing.T) {
+		obj := objectWithHooks{Name: " Test Object "}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectExec("INSERT INTO `objects`").
+			WithArgs("Test Object").
+			WillReturnResult(sqlmock.NewResult(7, 1))
+		require.NoError(t, NewDatabase(db).Insert(&obj))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, []string{"BeforeInsert", "AfterInsert 7"}, obj.calls)
+	})
+	t.Run("update and delete", func(t *testing.T) {
+		obj := objectWithHooks{Id: 1, Name: "Test Object"}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectExec("UPDATE `objects`").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM `objects`").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		database := NewDatabase(db)
+		require.NoError(t, database.Update(&obj))
+		require.NoError(t, database.Delete(&obj))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, []string{"BeforeUpdate", "AfterUpdate", "BeforeDelete", "AfterDelete"}, obj.calls)
+	})
+	t.Run("unchanged update", func(t *testing.T) {
+		obj := objectWithHooks{Id: 1, Name: "Test Object"}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		database := NewDatabase(db)
+		require.NoError(t, database.Track(&obj))
+		defer database.Untrack(&obj)
+		require.NoError(t, database.Update(&obj))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, []string{"BeforeUpdate", "AfterUpdate"}, obj.calls)
+	})
+	t.Run("select", func(t *testing.T) {
+		objs := []objectWithHooks{}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("SELECT `id`,`name` FROM `objects`").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "First").AddRow(2, "Second"))
+		require.NoError(t, NewDatabase(db).Find(&objs))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		if assert.Len(t, objs, 2) {
+			assert.Equal(t, []string{"AfterSelect First"}, objs[0].calls)
+			assert.Equal(t, []string{"AfterSelect Second"}, objs[1].calls)
+		}
+	})
+	t.Run("abort", func(t *testing.T) {
+		hookErr := fmt.Errorf("Invalid object.")
+		obj := objectWithHooks{Id: 1, err: hookErr}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		database := NewDatabase(db)
+		assert.Equal(t, hookErr, database.Insert(&obj))
+		assert.Equal(t, hookErr, database.Update(&obj))
+		assert.Equal(t, hookErr, database.Delete(&obj))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("transaction", func(t *testing.T) {
+		hookErr := fmt.Errorf("Invalid object.")
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `objects`").
+			WillReturnResult(sqlmock.NewResult(7, 1))
+		mock.ExpectRollback()
+		err = NewDatabase(db).WithTransaction(context.Background(), func(tx *Tx) error {
+			obj := objectWithHooks{Name: "Test Object"}
+			if err := tx.Insert(&obj); err != nil {
+				return err
+			}
+			obj.err = hookErr
+			return tx.Update(&obj)
+		})
+		assert.Equal(t, hookErr, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("insert many", func(t *testing.T) {
+		objs := []*objectWithAuditHook{{Name: "First"}, {Name: "Second"}}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO audit").WithArgs("First").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO audit").WithArgs("Second").WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectExec("INSERT INTO `objects` \\(`name`\\) VALUES \\(\\?\\),\\(\\?\\)").
+			WithArgs("First", "Second").
+			WillReturnResult(sqlmock.NewResult(7, 2))
+		mock.ExpectCommit()
+		affected, err := NewDatabase(db).InsertMany(objs)
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, int64(2), affected)
+	})
+	t.Run("insert many abort", func(t *testing.T) {
+		hookErr := fmt.Errorf("Invalid object.")
+		objs := []*objectWithAuditHook{{Name: "First"}, {Name: "Second", err: hookErr}}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		// The rows written by the hooks are rolled back along with the insert.
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO audit").WithArgs("First").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO audit").WithArgs("Second").WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectRollback()
+		_, err = NewDatabase(db).InsertMany(objs)
+		assert.Equal(t, hookErr, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("select in transaction", func(t *testing.T) {
+		objs := []objectWithSelectAuditHook{}
+		selectLog = nil
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		// The hooks are only called once every row has been scanned, as the rows must be closed before they can query.
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT `id`,`name` FROM `objects`").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "First").AddRow(2, "Second"))
+		mock.ExpectExec("INSERT INTO audit").WithArgs("First").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO audit").WithArgs("Second").WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+		err = NewDatabase(db).WithTransaction(context.Background(), func(tx *Tx) error {
+			return tx.Find(&objs)
+		})
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, []string{"Scan First", "Scan Second", "AfterSelect First", "AfterSelect Second"}, selectLog)
+	})
+}
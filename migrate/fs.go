@@ -0,0 +1,61 @@
+package migrate
+
+import (
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// fileNamePattern matches migration file names such as '0001_create_people.up.sql'.
+var fileNamePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// ReadFS returns the SQL migrations read from the '.sql' files in the root directory of the passed file system, such
+// as an embed.FS or os.DirFS. Each migration is read from a file named '<version>_<name>.up.sql' and, optionally, a
+// file named '<version>_<name>.down.sql' which rolls it back. Use fs.Sub to read a subdirectory.
+func ReadFS(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to read migrations.")
+	}
+	migrations := make([]*Migration, 0)
+	versions := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		matches := fileNamePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, errors.Errorf("Unable to read migration '%s' (expected a name such as '0001_create_people.up.sql').", entry.Name())
+		}
+		version, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Unable to read migration '%s' (invalid version).", entry.Name())
+		}
+		migration, ok := versions[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: matches[2]}
+			versions[version] = migration
+			migrations = append(migrations, migration)
+		} else if migration.Name != matches[2] {
+			return nil, errors.Errorf("Migrations '%s' and '%s' both have version %d.", migration.Name, matches[2], version)
+		}
+		contents, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, errors.Wrapf(err, "Unable to read migration '%s'.", entry.Name())
+		}
+		if matches[3] == "up" {
+			migration.UpSQL = string(contents)
+		} else {
+			migration.DownSQL = string(contents)
+		}
+	}
+	for _, migration := range migrations {
+		if migration.UpSQL == "" {
+			return nil, errors.Errorf("Unable to read migration %d '%s' (missing or empty up file).", migration.Version, migration.Name)
+		}
+	}
+	return migrations, nil
+}
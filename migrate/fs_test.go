@@ -0,0 +1,45 @@
+package migrate_test
+
+import (
+	. "github.com/dtucker2/database/migrate"
+
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_add_email.up.sql":       {Data: []byte("ALTER TABLE people ADD email varchar(255)")},
+		"0001_create_people.up.sql":   {Data: []byte("CREATE TABLE people (name varchar(255))")},
+		"0001_create_people.down.sql": {Data: []byte("DROP TABLE people")},
+		"README.md":                   {Data: []byte("Ignored.")},
+	}
+	migrations, err := ReadFS(fsys)
+	require.NoError(t, err)
+	assert.Equal(t, []*Migration{
+		{
+			Version: 1,
+			Name:    "create_people",
+			UpSQL:   "CREATE TABLE people (name varchar(255))",
+			DownSQL: "DROP TABLE people",
+		},
+		{
+			Version: 2,
+			Name:    "add_email",
+			UpSQL:   "ALTER TABLE people ADD email varchar(255)",
+		},
+	}, migrations)
+
+	_, err = ReadFS(fstest.MapFS{"create_people.sql": {Data: []byte("CREATE TABLE people (name varchar(255))")}})
+	assert.Error(t, err)
+	_, err = ReadFS(fstest.MapFS{"0001_create_people.down.sql": {Data: []byte("DROP TABLE people")}})
+	assert.Error(t, err)
+	_, err = ReadFS(fstest.MapFS{
+		"0001_create_people.up.sql": {Data: []byte("CREATE TABLE people (name varchar(255))")},
+		"0001_create_pets.up.sql":   {Data: []byte("CREATE TABLE pets (name varchar(255))")},
+	})
+	assert.Error(t, err)
+}
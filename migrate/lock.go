@@ -0,0 +1,98 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"hash/crc32"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// lock takes an advisory lock named after the migrations table, waiting until it is acquired or the context is done,
+// so that only one instance migrates the database at a time. The lock is held by a connection reserved until the
+// returned function releases it.
+// SQLite has no advisory locks, migrations of an SQLite database are not locked.
+func (migrator *Migrator) lock(ctx context.Context) (func() error, error) {
+	dialect := migrator.db.Dialect().Name()
+	if dialect == "sqlite3" {
+		return func() error { return nil }, nil
+	}
+	conn, err := migrator.db.Conn(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to lock migrations (failed to reserve a connection).")
+	}
+	var acquire, release string
+	var key interface{} = migrator.tableName
+	switch dialect {
+	case "mysql":
+		// MySQL locks are shared by every database on the server.
+		acquire = "SELECT GET_LOCK(CONCAT(DATABASE(), '.', ?), -1)"
+		release = "SELECT RELEASE_LOCK(CONCAT(DATABASE(), '.', ?))"
+	case "postgres":
+		// PostgreSQL locks are identified by a number rather than a name.
+		key = int64(crc32.ChecksumIEEE([]byte(migrator.tableName)))
+		acquire = "SELECT 1 FROM (SELECT pg_advisory_lock($1)) AS acquired"
+		release = "SELECT pg_advisory_unlock($1)"
+	case "sqlserver":
+		acquire = "DECLARE @result int; EXEC @result = sp_getapplock @Resource=@p1, @LockMode='Exclusive', " +
+			"@LockOwner='Session', @LockTimeout=-1; SELECT CASE WHEN @result >= 0 THEN 1 ELSE 0 END"
+		release = "EXEC sp_releaseapplock @Resource=@p1, @LockOwner='Session'"
+	default:
+		conn.Close()
+		return nil, errors.Errorf("Unable to lock migrations (dialect '%s' is not supported).", dialect)
+	}
+	var acquired sql.NullInt64
+	if err := conn.QueryRowContext(ctx, acquire, key).Scan(&acquired); err != nil || acquired.Int64 != 1 {
+		conn.Close()
+		if err == nil {
+			err = errors.New("Lock not granted.")
+		}
+		return nil, errors.Wrap(err, "Unable to lock migrations.")
+	}
+	return func() error {
+		// Closing the connection releases the lock regardless, as it is held by the session.
+		defer conn.Close()
+		if _, err := conn.ExecContext(context.Background(), release, key); err != nil {
+			return errors.Wrap(err, "Unable to unlock migrations.")
+		}
+		return nil
+	}, nil
+}
+
+// tableExists reports whether the table recording the applied migrations exists. A schema qualified table name (e.g.
+// 'schema.table') is looked up in that schema, otherwise in the connection's current schema.
+func (migrator *Migrator) tableExists(ctx context.Context) (bool, error) {
+	schema, table := "", migrator.tableName
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		schema, table = table[:i], table[i+1:]
+	}
+	var query string
+	args := []interface{}{schema, table}
+	switch dialect := migrator.db.Dialect().Name(); dialect {
+	case "mysql":
+		query = "SELECT COUNT(*) FROM information_schema.tables " +
+			"WHERE table_schema = COALESCE(NULLIF(?, ''), DATABASE()) AND table_name = ?"
+	case "postgres":
+		query = "SELECT COUNT(*) FROM information_schema.tables " +
+			"WHERE table_schema = COALESCE(NULLIF($1, ''), current_schema()) AND table_name = $2"
+	case "sqlite3":
+		// Each attached database, SQLite's equivalent of a schema, has its own sqlite_master table.
+		master := "sqlite_master"
+		if schema != "" {
+			master = migrator.db.Dialect().QuoteIdentifier(schema) + "." + master
+		}
+		query = "SELECT COUNT(*) FROM " + master + " WHERE type = 'table' AND name = ?"
+		args = []interface{}{table}
+	case "sqlserver":
+		query = "SELECT COUNT(*) FROM INFORMATION_SCHEMA.TABLES " +
+			"WHERE TABLE_SCHEMA = COALESCE(NULLIF(@p1, ''), SCHEMA_NAME()) AND TABLE_NAME = @p2"
+	default:
+		return false, errors.Errorf("Unable to find table '%s' (dialect '%s' is not supported).", migrator.tableName, dialect)
+	}
+	var count int
+	if err := migrator.db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return false, errors.Wrapf(err, "Unable to find table '%s'.", migrator.tableName)
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,365 @@
+// Package migrate applies versioned changes to a database's schema, recording the versions applied in a table.
+//
+// Migrations are either Go functions or SQL, typically read from '.sql' files using ReadFS, and are run in order of
+// version:
+//
+//	migrations, err := migrate.ReadFS(os.DirFS("migrations"))
+//	migrator, err := migrate.New(db, migrations)
+//	err = migrator.Up()
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/dtucker2/database"
+)
+
+// DefaultTableName is the name of the table recording the applied migrations, unless changed using WithTableName.
+const DefaultTableName = "schema_migrations"
+
+// Func applies or rolls back a migration within the passed transaction.
+type Func func(ctx context.Context, tx *database.Tx) error
+
+// Migration is a single versioned change to the schema. Each migration is applied within a transaction, along with
+// the recording of its version, so a failed migration is rolled back. Note that MySQL implicitly commits the
+// transaction after each schema change, so a MySQL migration should make a single schema change.
+type Migration struct {
+	// Version orders the migrations. It must be positive and unique.
+	Version int64
+	// Name describes the migration.
+	Name string
+	// Up applies the migration. When nil, UpSQL is executed instead.
+	Up Func
+	// Down rolls back the migration. When nil, DownSQL is executed instead.
+	Down Func
+	// UpSQL is executed to apply the migration when Up is nil. It is executed as a single query, MySQL requires the
+	// 'multiStatements=true' connection parameter to execute more than one statement.
+	UpSQL string
+	// DownSQL is executed to roll back the migration when Down is nil. The migration cannot be rolled back when both
+	// Down and DownSQL are empty.
+	DownSQL string
+}
+
+// Status describes whether a migration has been applied.
+type Status struct {
+	// Version is the version of the migration.
+	Version int64
+	// Name is the name of the migration.
+	Name string
+	// Applied reports whether the migration has been applied.
+	Applied bool
+	// AppliedAt is the time the migration was applied, or nil if it has not been applied.
+	AppliedAt *time.Time
+	// Missing reports whether the migration has been applied but is not one of the Migrator's migrations, e.g. as
+	// it was applied by a newer version of the application.
+	Missing bool
+}
+
+// Migrator applies and rolls back migrations, created using New.
+type Migrator struct {
+	db         *database.Database
+	migrations []*Migration
+	tableName  string
+	dryRun     io.Writer
+}
+
+// Option configures a Migrator.
+type Option func(*Migrator)
+
+// WithTableName sets the name of the table recording the applied migrations, DefaultTableName by default.
+func WithTableName(name string) Option {
+	return func(migrator *Migrator) {
+		migrator.tableName = name
+	}
+}
+
+// DryRun writes the migrations that would be applied or rolled back to the passed writer instead of executing them.
+// Nothing is written to the database, not even the table recording the applied migrations.
+func DryRun(w io.Writer) Option {
+	return func(migrator *Migrator) {
+		migrator.dryRun = w
+	}
+}
+
+// record is a row of the table recording the applied migrations.
+type record struct {
+	Version   int64     `name:"version" key:"true"`
+	Name      string    `name:"name"`
+	AppliedAt time.Time `name:"applied_at" type:"created_at"`
+	table     string
+}
+
+func (row *record) GetTableName() string {
+	return row.table
+}
+
+// step is a migration to apply (up) or roll back.
+type step struct {
+	migration *Migration
+	up        bool
+}
+
+// New returns a Migrator running the passed migrations against the passed database. An error is returned if any
+// migration has an invalid or duplicate version, or is missing both Up and UpSQL.
+func New(db *database.Database, migrations []*Migration, options ...Option) (*Migrator, error) {
+	migrator := &Migrator{
+		db:         db,
+		migrations: append([]*Migration(nil), migrations...),
+		tableName:  DefaultTableName,
+	}
+	for _, option := range options {
+		option(migrator)
+	}
+	sort.SliceStable(migrator.migrations, func(i, j int) bool {
+		return migrator.migrations[i].Version < migrator.migrations[j].Version
+	})
+	for i, migration := range migrator.migrations {
+		if migration.Version <= 0 {
+			return nil, errors.Errorf("Invalid version %d for migration '%s' (expected a positive version).", migration.Version, migration.Name)
+		}
+		if i > 0 && migrator.migrations[i-1].Version == migration.Version {
+			return nil, errors.Errorf("Migrations '%s' and '%s' both have version %d.", migrator.migrations[i-1].Name, migration.Name, migration.Version)
+		}
+		if migration.Up == nil && migration.UpSQL == "" {
+			return nil, errors.Errorf("Invalid migration %d '%s' (missing Up or UpSQL).", migration.Version, migration.Name)
+		}
+	}
+	return migrator, nil
+}
+
+// Up applies every migration which has not been applied, in order of version.
+func (migrator *Migrator) Up() error {
+	return migrator.UpContext(context.Background())
+}
+
+// UpContext is the same as Up but executes the migrations using the passed context.
+func (migrator *Migrator) UpContext(ctx context.Context) error {
+	return migrator.run(ctx, func(applied map[int64]*record) ([]step, error) {
+		return migrator.plan(applied, migrator.migrations[len(migrator.migrations)-1].Version)
+	})
+}
+
+// Down rolls back the most recently applied migration, that with the highest version.
+func (migrator *Migrator) Down() error {
+	return migrator.DownContext(context.Background())
+}
+
+// DownContext is the same as Down but executes the migration using the passed context.
+func (migrator *Migrator) DownContext(ctx context.Context) error {
+	return migrator.run(ctx, func(applied map[int64]*record) ([]step, error) {
+		versions := sortedVersions(applied)
+		if len(versions) == 0 {
+			return nil, nil
+		}
+		down, err := migrator.rollback(applied, versions[len(versions)-1])
+		if err != nil {
+			return nil, err
+		}
+		return []step{down}, nil
+	})
+}
+
+// To applies or rolls back migrations until those with a version up to and including the passed version are
+// applied, and those with a higher version are not. A version of zero rolls back every migration.
+func (migrator *Migrator) To(version int64) error {
+	return migrator.ToContext(context.Background(), version)
+}
+
+// ToContext is the same as To but executes the migrations using the passed context.
+func (migrator *Migrator) ToContext(ctx context.Context, version int64) error {
+	if version != 0 && migrator.find(version) == nil {
+		return errors.Errorf("Unable to migrate to version %d (no such migration).", version)
+	}
+	return migrator.run(ctx, func(applied map[int64]*record) ([]step, error) {
+		return migrator.plan(applied, version)
+	})
+}
+
+// Status returns the status of every migration, including those applied which are not one of the Migrator's
+// migrations, in order of version.
+func (migrator *Migrator) Status() ([]Status, error) {
+	return migrator.StatusContext(context.Background())
+}
+
+// StatusContext is the same as Status but executes the queries using the passed context.
+func (migrator *Migrator) StatusContext(ctx context.Context) ([]Status, error) {
+	applied, err := migrator.loadApplied(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(migrator.migrations))
+	for _, migration := range migrator.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if row, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &row.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	for _, version := range sortedVersions(applied) {
+		if migrator.find(version) == nil {
+			row := applied[version]
+			statuses = append(statuses, Status{
+				Version:   version,
+				Name:      row.Name,
+				Applied:   true,
+				AppliedAt: &row.AppliedAt,
+				Missing:   true,
+			})
+		}
+	}
+	sort.SliceStable(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// run locks the database, plans the steps to take from the applied migrations and then takes them. Nothing is locked
+// or written during a dry run.
+func (migrator *Migrator) run(ctx context.Context, plan func(applied map[int64]*record) ([]step, error)) (err error) {
+	if len(migrator.migrations) == 0 {
+		return nil
+	}
+	if migrator.dryRun == nil {
+		unlock, err := migrator.lock(ctx)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if unlockErr := unlock(); err == nil {
+				err = unlockErr
+			}
+		}()
+	}
+	applied, err := migrator.loadApplied(ctx, migrator.dryRun == nil)
+	if err != nil {
+		return err
+	}
+	steps, err := plan(applied)
+	if err != nil {
+		return err
+	}
+	for _, step := range steps {
+		if err := migrator.take(ctx, step); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// plan returns the steps migrating from the applied migrations to the passed version: the applied migrations with a
+// higher version are rolled back, newest first, then the remaining migrations up to the version are applied.
+func (migrator *Migrator) plan(applied map[int64]*record, version int64) ([]step, error) {
+	steps := make([]step, 0)
+	versions := sortedVersions(applied)
+	for i := len(versions) - 1; i >= 0 && versions[i] > version; i-- {
+		down, err := migrator.rollback(applied, versions[i])
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, down)
+	}
+	for _, migration := range migrator.migrations {
+		if _, ok := applied[migration.Version]; !ok && migration.Version <= version {
+			steps = append(steps, step{migration: migration, up: true})
+		}
+	}
+	return steps, nil
+}
+
+// rollback returns the step rolling back the applied migration with the passed version.
+func (migrator *Migrator) rollback(applied map[int64]*record, version int64) (step, error) {
+	migration := migrator.find(version)
+	if migration == nil {
+		return step{}, errors.Errorf("Unable to roll back migration %d '%s' (no such migration).", version, applied[version].Name)
+	}
+	if migration.Down == nil && migration.DownSQL == "" {
+		return step{}, errors.Errorf("Unable to roll back migration %d '%s' (missing Down or DownSQL).", version, migration.Name)
+	}
+	return step{migration: migration}, nil
+}
+
+// take applies or rolls back a migration, recording its version within the same transaction.
+func (migrator *Migrator) take(ctx context.Context, step step) error {
+	migration := step.migration
+	fn, query, direction := migration.Down, migration.DownSQL, "down"
+	if step.up {
+		fn, query, direction = migration.Up, migration.UpSQL, "up"
+	}
+	if migrator.dryRun != nil {
+		if fn != nil {
+			query = "-- Go function"
+		}
+		_, err := fmt.Fprintf(migrator.dryRun, "-- %d %s (%s)\n%s\n", migration.Version, migration.Name, direction, query)
+		return err
+	}
+	err := migrator.db.WithTransaction(ctx, func(tx *database.Tx) error {
+		if fn != nil {
+			if err := fn(ctx, tx); err != nil {
+				return err
+			}
+		} else if _, err := tx.ExecContext(ctx, query); err != nil {
+			return err
+		}
+		row := &record{Version: migration.Version, Name: migration.Name, table: migrator.tableName}
+		if step.up {
+			return tx.InsertContext(ctx, row)
+		}
+		return tx.DeleteContext(ctx, row)
+	})
+	return errors.Wrapf(err, "Unable to migrate %s %d '%s'.", direction, migration.Version, migration.Name)
+}
+
+// loadApplied returns the applied migrations keyed by version. When the table recording them does not exist it is
+// created if create is true, otherwise no migrations are returned.
+func (migrator *Migrator) loadApplied(ctx context.Context, create bool) (map[int64]*record, error) {
+	model := &record{table: migrator.tableName}
+	exists, err := migrator.tableExists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if create {
+			if err := migrator.db.CreateTableContext(ctx, model); err != nil {
+				return nil, errors.Wrapf(err, "Unable to create table '%s'.", migrator.tableName)
+			}
+		}
+		return map[int64]*record{}, nil
+	}
+	rows := make([]*record, 0)
+	if err := migrator.db.Model(model).FindContext(ctx, &rows); err != nil {
+		return nil, errors.Wrapf(err, "Unable to read applied migrations from '%s'.", migrator.tableName)
+	}
+	applied := make(map[int64]*record, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+func (migrator *Migrator) find(version int64) *Migration {
+	i := sort.Search(len(migrator.migrations), func(i int) bool {
+		return migrator.migrations[i].Version >= version
+	})
+	if i < len(migrator.migrations) && migrator.migrations[i].Version == version {
+		return migrator.migrations[i]
+	}
+	return nil
+}
+
+func sortedVersions(applied map[int64]*record) []int64 {
+	versions := make([]int64, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i] < versions[j]
+	})
+	return versions
+}
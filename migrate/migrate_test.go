@@ -0,0 +1,238 @@
+package migrate_test
+
+import (
+	. "github.com/dtucker2/database/migrate"
+
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dtucker2/database"
+	"github.com/dtucker2/database/query"
+)
+
+type anyTime struct{}
+
+// Match satisfies sqlmock.Argument interface.
+func (a anyTime) Match(v driver.Value) bool {
+	_, ok := v.(time.Time)
+	return ok
+}
+
+func newMigrations() []*Migration {
+	return []*Migration{
+		{
+			Version: 2,
+			Name:    "add_email",
+			Up: func(ctx context.Context, tx *database.Tx) error {
+				_, err := tx.ExecContext(ctx, "ALTER TABLE people ADD email varchar(255)")
+				return err
+			},
+		},
+		{
+			Version: 1,
+			Name:    "create_people",
+			UpSQL:   "CREATE TABLE people (name varchar(255))",
+			DownSQL: "DROP TABLE people",
+		},
+	}
+}
+
+func TestMigrator_Up(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	mock.ExpectQuery("SELECT GET_LOCK\\(CONCAT\\(DATABASE\\(\\), '.', \\?\\), -1\\)").
+		WithArgs("schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"acquired"}).AddRow(1))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM information_schema.tables").
+		WithArgs("", "schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec("CREATE TABLE `schema_migrations` \\(`version` BIGINT NOT NULL,`name` VARCHAR\\(255\\) NOT NULL," +
+		"`applied_at` TIMESTAMP NOT NULL,PRIMARY KEY \\(`version`\\)\\)").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE TABLE people").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO `schema_migrations` \\(`version`,`name`,`applied_at`\\) VALUES \\(\\?,\\?,\\?\\)").
+		WithArgs(1, "create_people", anyTime{}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("ALTER TABLE people").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO `schema_migrations`").
+		WithArgs(2, "add_email", anyTime{}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("SELECT RELEASE_LOCK\\(CONCAT\\(DATABASE\\(\\), '.', \\?\\)\\)").
+		WithArgs("schema_migrations").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	migrator, err := New(database.NewDatabase(db), newMigrations())
+	require.NoError(t, err)
+	require.NoError(t, migrator.Up())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Up_Error(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM sqlite_master").
+		WithArgs("schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`SELECT "version","name","applied_at" FROM "schema_migrations"`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "name", "applied_at"}).AddRow(1, "create_people", time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec("ALTER TABLE people").WillReturnError(errors.New("duplicate column"))
+	mock.ExpectRollback()
+	migrator, err := New(database.NewDatabase(db, database.WithDialect(query.SQLite)), newMigrations())
+	require.NoError(t, err)
+	err = migrator.Up()
+	assert.EqualError(t, err, "Unable to migrate up 2 'add_email'.: duplicate column")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Down(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	mock.ExpectQuery("SELECT 1 FROM \\(SELECT pg_advisory_lock\\(\\$1\\)\\) AS acquired").
+		WillReturnRows(sqlmock.NewRows([]string{"acquired"}).AddRow(1))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM information_schema.tables`).
+		WithArgs("", "schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`SELECT "version","name","applied_at" FROM "schema_migrations"`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "name", "applied_at"}).AddRow(1, "create_people", time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec("DROP TABLE people").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM "schema_migrations" WHERE "version"=\$1`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+	migrator, err := New(database.NewDatabase(db, database.WithDialect(query.PostgreSQL)), newMigrations())
+	require.NoError(t, err)
+	require.NoError(t, migrator.Down())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_To(t *testing.T) {
+	t.Run("down", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM sqlite_master").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(`SELECT "version","name","applied_at" FROM "schema_migrations"`).
+			WillReturnRows(sqlmock.NewRows([]string{"version", "name", "applied_at"}).
+				AddRow(1, "create_people", time.Now()).
+				AddRow(2, "add_email", time.Now()))
+		migrator, err := New(database.NewDatabase(db, database.WithDialect(query.SQLite)), newMigrations())
+		require.NoError(t, err)
+		// Migration 2 has no down migration, so nothing is rolled back.
+		assert.Error(t, migrator.To(0))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("up", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM sqlite_master").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(`SELECT "version","name","applied_at" FROM "schema_migrations"`).
+			WillReturnRows(sqlmock.NewRows([]string{"version", "name", "applied_at"}))
+		mock.ExpectBegin()
+		mock.ExpectExec("CREATE TABLE people").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO \"schema_migrations\"").
+			WithArgs(1, "create_people", anyTime{}).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		migrator, err := New(database.NewDatabase(db, database.WithDialect(query.SQLite)), newMigrations())
+		require.NoError(t, err)
+		require.NoError(t, migrator.To(1))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("unknown version", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		migrator, err := New(database.NewDatabase(db, database.WithDialect(query.SQLite)), newMigrations())
+		require.NoError(t, err)
+		assert.Error(t, migrator.To(3))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMigrator_Status(t *testing.T) {
+	appliedAt := time.Date(2019, 1, 28, 23, 20, 0, 0, time.UTC)
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM sqlite_master").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`SELECT "version","name","applied_at" FROM "schema_migrations"`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "name", "applied_at"}).
+			AddRow(1, "create_people", appliedAt).
+			AddRow(3, "add_age", appliedAt))
+	migrator, err := New(database.NewDatabase(db, database.WithDialect(query.SQLite)), newMigrations())
+	require.NoError(t, err)
+	statuses, err := migrator.Status()
+	require.NoError(t, err)
+	assert.Equal(t, []Status{
+		{Version: 1, Name: "create_people", Applied: true, AppliedAt: &appliedAt},
+		{Version: 2, Name: "add_email"},
+		{Version: 3, Name: "add_age", Applied: true, AppliedAt: &appliedAt, Missing: true},
+	}, statuses)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_DryRun(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	// The migrations table is neither locked nor created.
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM information_schema.tables").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	output := &bytes.Buffer{}
+	migrator, err := New(database.NewDatabase(db), newMigrations(), DryRun(output))
+	require.NoError(t, err)
+	require.NoError(t, migrator.Up())
+	assert.Equal(t, "-- 1 create_people (up)\nCREATE TABLE people (name varchar(255))\n"+
+		"-- 2 add_email (up)\n-- Go function\n", output.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_WithTableName(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM information_schema.tables").
+		WithArgs("app", "versions").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	migrator, err := New(database.NewDatabase(db), newMigrations(), WithTableName("app.versions"))
+	require.NoError(t, err)
+	statuses, err := migrator.Status()
+	require.NoError(t, err)
+	assert.Len(t, statuses, 2)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM "app".sqlite_master`).
+		WithArgs("versions").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	migrator, err = New(database.NewDatabase(db, database.WithDialect(query.SQLite)), newMigrations(), WithTableName("app.versions"))
+	require.NoError(t, err)
+	_, err = migrator.Status()
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNew(t *testing.T) {
+	sqlDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+	db := database.NewDatabase(sqlDB)
+	_, err = New(db, []*Migration{{Version: 0, Name: "zero", UpSQL: "SELECT 1"}})
+	assert.Error(t, err)
+	_, err = New(db, []*Migration{{Version: 1, Name: "empty"}})
+	assert.Error(t, err)
+	_, err = New(db, []*Migration{
+		{Version: 1, Name: "first", UpSQL: "SELECT 1"},
+		{Version: 1, Name: "second", UpSQL: "SELECT 2"},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,273 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/dtucker2/database/query"
+)
+
+// SelectOption configures a Select.
+type SelectOption func(*selectOptions)
+
+type selectOptions struct {
+	preloads []string
+}
+
+// Preload loads the passed relations (fields tagged with 'rel') of the selected struct, using one extra query per
+// relation. Relations of relations are loaded using dotted paths, e.g. Preload("LineItems.Product").
+func Preload(relations ...string) SelectOption {
+	return func(options *selectOptions) {
+		options.preloads = append(options.preloads, relations...)
+	}
+}
+
+func newSelectOptions(options []SelectOption) *selectOptions {
+	selectOptions := &selectOptions{}
+	for _, option := range options {
+		option(selectOptions)
+	}
+	return selectOptions
+}
+
+// preload loads the passed relation paths into every struct of owners, a slice of struct pointers.
+func (db *Database) preload(ctx context.Context, exec executor, owners reflect.Value, paths []string) error {
+	for _, path := range paths {
+		if err := db.preloadPath(ctx, exec, owners, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *Database) preloadPath(ctx context.Context, exec executor, owners reflect.Value, path string) error {
+	if owners.Len() == 0 {
+		return nil
+	}
+	name, rest := path, ""
+	if i := strings.Index(path, "."); i >= 0 {
+		name, rest = path[:i], path[i+1:]
+	}
+	metadata, err := query.GetMetadata(owners.Type().Elem())
+	if err != nil {
+		return err
+	}
+	relation, ok := metadata.Relation(name)
+	if !ok {
+		return errors.Errorf("Unable to preload '%s' (no such relation on '%s').", name, metadata.Type)
+	}
+	ownerKey, relatedKey, err := getRelationKeys(metadata, relation)
+	if err != nil {
+		return err
+	}
+	values := make([]interface{}, 0, owners.Len())
+	seen := make(map[string]bool, owners.Len())
+	for i := 0; i < owners.Len(); i++ {
+		value, key, ok := getRelationKey(ownerKey.Value(owners.Index(i).Elem()))
+		if ok && !seen[key] {
+			seen[key] = true
+			values = append(values, value)
+		}
+	}
+	var related reflect.Value
+	var relatedKeys []string
+	if relation.Kind == query.ManyToMany {
+		related, relatedKeys, err = db.findJoined(ctx, exec, relation, values)
+	} else {
+		related, err = db.findRelated(ctx, exec, relation, relatedKey, values)
+	}
+	if err != nil {
+		return err
+	}
+	// Nested relations are loaded before the related structs are copied into their owners.
+	if rest != "" {
+		if err := db.preloadPath(ctx, exec, related, rest); err != nil {
+			return errors.Wrapf(err, "Failed to preload '%s'.", path)
+		}
+	}
+	groups := make(map[string][]reflect.Value, related.Len())
+	for i := 0; i < related.Len(); i++ {
+		if relatedKeys != nil {
+			// Joined rows are matched using the join table's foreign key rather than a field of the related struct.
+			groups[relatedKeys[i]] = append(groups[relatedKeys[i]], related.Index(i))
+		} else if _, key, ok := getRelationKey(relatedKey.Value(related.Index(i).Elem())); ok {
+			groups[key] = append(groups[key], related.Index(i))
+		}
+	}
+	for i := 0; i < owners.Len(); i++ {
+		owner := owners.Index(i).Elem()
+		_, key, _ := getRelationKey(ownerKey.Value(owner))
+		setRelation(owner.FieldByIndex(relation.Index), relation, groups[key])
+	}
+	return nil
+}
+
+// findRelated returns a slice of pointers to the related structs whose key column matches one of the passed values.
+// The values are split across several queries when they exceed the dialect's parameter limit.
+func (db *Database) findRelated(ctx context.Context, exec executor, relation *query.Relation, relatedKey *query.Field, values []interface{}) (reflect.Value, error) {
+	related := reflect.MakeSlice(reflect.SliceOf(reflect.PtrTo(relation.Type)), 0, len(values))
+	chunkSize := db.Dialect().MaxParameters()
+	for start := 0; start < len(values); start += chunkSize {
+		end := start + chunkSize
+		if end > len(values) {
+			end = len(values)
+		}
+		dest := reflect.New(related.Type())
+		criteria := query.NewCriteria().WhereIn(relatedKey.Column, values[start:end]...)
+		if err := db.findCriteria(ctx, exec, nil, dest.Interface(), criteria, nil); err != nil {
+			return reflect.Value{}, errors.Wrapf(err, "Failed to preload '%s'.", relation.Name)
+		}
+		related = reflect.AppendSlice(related, dest.Elem())
+	}
+	return related, nil
+}
+
+// findJoined returns a slice of pointers to the related structs of the passed ManyToMany relation linked to one of the
+// passed owner keys, along with the key of the owner each is linked to.
+func (db *Database) findJoined(ctx context.Context, exec executor, relation *query.Relation, ownerKeys []interface{}) (reflect.Value, []string, error) {
+	related := reflect.MakeSlice(reflect.SliceOf(reflect.PtrTo(relation.Type)), 0, len(ownerKeys))
+	keys := make([]string, 0, len(ownerKeys))
+	chunkSize := db.Dialect().MaxParameters()
+	for start := 0; start < len(ownerKeys); start += chunkSize {
+		end := start + chunkSize
+		if end > len(ownerKeys) {
+			end = len(ownerKeys)
+		}
+		query, args, err := db.BuildManyToManyQuery(relation, ownerKeys[start:end])
+		if err != nil {
+			return reflect.Value{}, nil, err
+		}
+		rows, err := exec.QueryContext(ctx, query, args...)
+		if err != nil {
+			return reflect.Value{}, nil, newQueryError(err)
+		}
+		columns, err := rows.Columns()
+		if err != nil {
+			rows.Close()
+			return reflect.Value{}, nil, errors.Wrap(err, "Failed to read columns.")
+		}
+		for rows.Next() {
+			elem := reflect.New(relation.Type)
+			ownerKey, err := db.scanJoinedRow(rows, columns, elem.Interface())
+			if err != nil {
+				rows.Close()
+				return reflect.Value{}, nil, err
+			}
+			_, key, _ := getRelationKey(reflect.ValueOf(&ownerKey).Elem())
+			related = reflect.Append(related, elem)
+			keys = append(keys, key)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return reflect.Value{}, nil, newQueryError(err)
+		}
+	}
+	if err := afterSelectAll(ctx, related); err != nil {
+		return reflect.Value{}, nil, err
+	}
+	return related, keys, nil
+}
+
+// getRelationKeys returns the fields compared to match the owner and related structs of the passed relation.
+func getRelationKeys(metadata *query.Metadata, relation *query.Relation) (*query.Field, *query.Field, error) {
+	relatedMetadata, err := query.GetMetadata(relation.Type)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The foreign key column belongs to the owner's table for BelongsTo relations and to the related table otherwise.
+	keyMetadata, foreignKeyMetadata := relatedMetadata, metadata
+	if relation.Kind != query.BelongsTo {
+		keyMetadata, foreignKeyMetadata = metadata, relatedMetadata
+	}
+	if len(keyMetadata.Keys) != 1 {
+		return nil, nil, errors.Errorf("Unable to preload '%s' ('%s' must have a single primary key field).", relation.Name, keyMetadata.Type)
+	}
+	if relation.Kind == query.ManyToMany {
+		// Both foreign key columns belong to the join table, the related key is only used by BuildManyToManyQuery.
+		return keyMetadata.Keys[0], nil, nil
+	}
+	foreignKey, ok := foreignKeyMetadata.Column(relation.ForeignKey)
+	if !ok {
+		return nil, nil, errors.Errorf("Unable to preload '%s' ('%s' has no column '%s').", relation.Name, foreignKeyMetadata.Type, relation.ForeignKey)
+	}
+	if relation.Kind == query.BelongsTo {
+		return foreignKey, keyMetadata.Keys[0], nil
+	}
+	return keyMetadata.Keys[0], foreignKey, nil
+}
+
+// getRelationKey returns the passed key field's value along with a string used to match it against keys of other types
+// (e.g. an int primary key against an int64 foreign key). Nil pointers and NULL values match nothing.
+func getRelationKey(field reflect.Value) (interface{}, string, bool) {
+	for field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return nil, "", false
+		}
+		field = field.Elem()
+	}
+	value := field.Interface()
+	if valuer, ok := value.(driver.Valuer); ok {
+		var err error
+		if value, err = valuer.Value(); err != nil || value == nil {
+			return nil, "", false
+		}
+	}
+	if bytes, ok := value.([]byte); ok {
+		return value, string(bytes), true
+	}
+	return value, fmt.Sprint(value), true
+}
+
+// setRelation sets the passed relation field to the matching related structs, passed as struct pointers.
+func setRelation(field reflect.Value, relation *query.Relation, matches []reflect.Value) {
+	if relation.Kind == query.HasMany || relation.Kind == query.ManyToMany {
+		slice := reflect.MakeSlice(relation.FieldType, 0, len(matches))
+		for _, match := range matches {
+			if relation.FieldType.Elem().Kind() == reflect.Ptr {
+				slice = reflect.Append(slice, match)
+			} else {
+				slice = reflect.Append(slice, match.Elem())
+			}
+		}
+		field.Set(slice)
+		return
+	}
+	switch {
+	case len(matches) == 0:
+		field.Set(reflect.Zero(relation.FieldType))
+	case relation.FieldType.Kind() == reflect.Ptr:
+		field.Set(matches[0])
+	default:
+		field.Set(matches[0].Elem())
+	}
+}
+
+// getRelationElements returns pointers to the related structs currently held by the passed slice relation field.
+func getRelationElements(field reflect.Value) []reflect.Value {
+	elements := make([]reflect.Value, field.Len())
+	for i := range elements {
+		elements[i] = field.Index(i)
+		if elements[i].Kind() != reflect.Ptr {
+			elements[i] = elements[i].Addr()
+		}
+	}
+	return elements
+}
+
+// getStructPointers returns a slice of pointers to the structs of the passed slice of structs or struct pointers.
+func getStructPointers(slice reflect.Value) reflect.Value {
+	if slice.Type().Elem().Kind() == reflect.Ptr {
+		return slice
+	}
+	pointers := reflect.MakeSlice(reflect.SliceOf(reflect.PtrTo(slice.Type().Elem())), slice.Len(), slice.Len())
+	for i := 0; i < slice.Len(); i++ {
+		pointers.Index(i).Set(slice.Index(i).Addr())
+	}
+	return pointers
+}
@@ -0,0 +1,91 @@
+package database_test
+
+import (
+	. "github.com/dtucker2/database"
+
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Customer struct {
+	Id   int    `name:"id" key:"true"`
+	Name string `name:"name"`
+}
+
+type Order struct {
+	Id         int        `name:"id" key:"true"`
+	CustomerId *int64     `name:"customer_id"`
+	Customer   *Customer  `rel:"belongs-to" fk:"customer_id"`
+	LineItems  []LineItem `rel:"has-many" fk:"order_id"`
+}
+
+type LineItem struct {
+	Id        int     `name:"id" key:"true"`
+	OrderId   int     `name:"order_id"`
+	ProductId int     `name:"product_id"`
+	Product   Product `rel:"belongs-to" fk:"product_id"`
+}
+
+type Product struct {
+	Id   int    `name:"id" key:"true"`
+	Name string `name:"name"`
+}
+
+func TestDatabase_Preload(t *testing.T) {
+	t.Run("select", func(t *testing.T) {
+		order := Order{Id: 1}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("SELECT `id`,`customer_id` FROM `Orders` WHERE `id`=\\?").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "customer_id"}).AddRow(1, 5))
+		mock.ExpectQuery("SELECT `id`,`name` FROM `Customers` WHERE `id` IN \\(\\?\\)").
+			WithArgs(int64(5)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(5, "Frank"))
+		mock.ExpectQuery("SELECT `id`,`order_id`,`product_id` FROM `LineItems` WHERE `order_id` IN \\(\\?\\)").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "product_id"}).AddRow(10, 1, 7).AddRow(11, 1, 7))
+		require.NoError(t, NewDatabase(db).Select(&order, Preload("Customer", "LineItems")))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, &Customer{Id: 5, Name: "Frank"}, order.Customer)
+		assert.Equal(t, []LineItem{{Id: 10, OrderId: 1, ProductId: 7}, {Id: 11, OrderId: 1, ProductId: 7}}, order.LineItems)
+	})
+	t.Run("find", func(t *testing.T) {
+		orders := []Order{}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("SELECT `id`,`customer_id` FROM `Orders`").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "customer_id"}).AddRow(1, 5).AddRow(2, nil).AddRow(3, 5))
+		mock.ExpectQuery("SELECT `id`,`order_id`,`product_id` FROM `LineItems` WHERE `order_id` IN \\(\\?,\\?,\\?\\)").
+			WithArgs(1, 2, 3).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "product_id"}).AddRow(10, 1, 7).AddRow(11, 3, 8))
+		mock.ExpectQuery("SELECT `id`,`name` FROM `Products` WHERE `id` IN \\(\\?,\\?\\)").
+			WithArgs(7, 8).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(7, "Widget").AddRow(8, "Gadget"))
+		mock.ExpectQuery("SELECT `id`,`name` FROM `Customers` WHERE `id` IN \\(\\?\\)").
+			WithArgs(int64(5)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(5, "Frank"))
+		require.NoError(t, NewDatabase(db).Model(&Order{}).Preload("LineItems.Product", "Customer").Find(&orders))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		require.Len(t, orders, 3)
+		assert.Equal(t, []LineItem{{Id: 10, OrderId: 1, ProductId: 7, Product: Product{Id: 7, Name: "Widget"}}}, orders[0].LineItems)
+		assert.Empty(t, orders[1].LineItems)
+		assert.Equal(t, "Gadget", orders[2].LineItems[0].Product.Name)
+		assert.Equal(t, "Frank", orders[0].Customer.Name)
+		assert.Nil(t, orders[1].Customer)
+		assert.True(t, orders[0].Customer == orders[2].Customer)
+	})
+	t.Run("unknown relation", func(t *testing.T) {
+		order := Order{Id: 1}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("SELECT `id`,`customer_id` FROM `Orders` WHERE `id`=\\?").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "customer_id"}).AddRow(1, 5))
+		assert.Error(t, NewDatabase(db).Select(&order, Preload("Missing")))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,96 @@
+package query_test
+
+import (
+	. "github.com/dtucker2/database/query"
+
+	"reflect"
+	"testing"
+)
+
+func BenchmarkQueryBuilder_BuildInsertQuery(b *testing.B) {
+	obj := objectWithTags{
+		Name: "Test Object",
+	}
+	builder := NewQueryBuilder()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		builder.BuildInsertQuery(&obj)
+	}
+}
+
+func BenchmarkQueryBuilder_BuildInsertQueryUncached(b *testing.B) {
+	obj := objectWithTags{
+		Name: "Test Object",
+	}
+	builder := NewQueryBuilder()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ClearMetadataCache()
+		builder.BuildInsertQuery(&obj)
+	}
+}
+
+func BenchmarkQueryBuilder_BuildUpdateQuery(b *testing.B) {
+	obj := objectWithTags{
+		Id:   1,
+		Name: "Test Object",
+	}
+	builder := NewQueryBuilder()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		builder.BuildUpdateQuery(&obj)
+	}
+}
+
+func BenchmarkQueryBuilder_BuildUpdateQueryUncached(b *testing.B) {
+	obj := objectWithTags{
+		Id:   1,
+		Name: "Test Object",
+	}
+	builder := NewQueryBuilder()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ClearMetadataCache()
+		builder.BuildUpdateQuery(&obj)
+	}
+}
+
+func BenchmarkQueryBuilder_BuildSelectQuery(b *testing.B) {
+	obj := object{
+		Id: 1,
+	}
+	builder := NewQueryBuilder()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		builder.BuildSelectQuery(&obj)
+	}
+}
+
+func BenchmarkQueryBuilder_BuildSelectQueryUncached(b *testing.B) {
+	obj := object{
+		Id: 1,
+	}
+	builder := NewQueryBuilder()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ClearMetadataCache()
+		builder.BuildSelectQuery(&obj)
+	}
+}
+
+func BenchmarkGetMetadata(b *testing.B) {
+	typ := reflect.TypeOf(&objectWithTags{})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		GetMetadata(typ)
+	}
+}
+
+func BenchmarkGetMetadataUncached(b *testing.B) {
+	typ := reflect.TypeOf(&objectWithTags{})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ClearMetadataCache()
+		GetMetadata(typ)
+	}
+}
@@ -0,0 +1,115 @@
+package query
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// indexOwnName is the 'index' tag value creating an index covering only the tagged column.
+const indexOwnName = "true"
+
+// BuildCreateTableQuery builds a CREATE TABLE query from the passed struct pointer. Column types are derived from the
+// Go type of each field, pointers and the sql.Null types giving nullable columns, and are refined by the 'size',
+// 'nullable', 'default' and 'unique' tags. Indexes are created by the queries built by BuildCreateIndexQueries.
+func (builder *QueryBuilder) BuildCreateTableQuery(object interface{}) (string, error) {
+	metadata, tableName, err := builder.getMetadataAndTableName(object)
+	if err != nil {
+		return "", err
+	}
+	definitions := make([]string, 0, len(metadata.Fields)+1)
+	keyDeclared := false
+	for _, field := range metadata.Fields {
+		definition, declaresKey, err := builder.buildColumnDefinition(metadata, field)
+		if err != nil {
+			return "", err
+		}
+		definitions = append(definitions, definition)
+		keyDeclared = keyDeclared || declaresKey
+	}
+	if len(metadata.Keys) > 0 && !keyDeclared {
+		keyColumns := make([]string, len(metadata.Keys))
+		for i, field := range metadata.Keys {
+			keyColumns[i] = builder.dialect.QuoteIdentifier(field.Column)
+		}
+		definitions = append(definitions, "PRIMARY KEY ("+strings.Join(keyColumns, ",")+")")
+	}
+	return "CREATE TABLE " + tableName + " (" + strings.Join(definitions, ",") + ")", nil
+}
+
+// BuildCreateIndexQueries builds a CREATE INDEX query for each index declared by the 'index' tags of the passed struct
+// pointer, in the order the indexes are first declared. Fields tagged 'index:"true"' are indexed as
+// 'idx_<table>_<column>', fields sharing any other index name form a multi-column index in declaration order.
+func (builder *QueryBuilder) BuildCreateIndexQueries(object interface{}) ([]string, error) {
+	metadata, tableName, err := builder.getMetadataAndTableName(object)
+	if err != nil {
+		return nil, err
+	}
+	// The index is named after the table without its schema, as the index is created within the table's schema.
+	unqualifiedTableName := builder.getTableName(metadata, object)
+	unqualifiedTableName = unqualifiedTableName[strings.LastIndex(unqualifiedTableName, ".")+1:]
+	names := make([]string, 0)
+	columns := make(map[string][]string)
+	for _, field := range metadata.Fields {
+		name := field.IndexName
+		switch name {
+		case "":
+			continue
+		case indexOwnName:
+			name = "idx_" + unqualifiedTableName + "_" + field.Column
+		}
+		if _, ok := columns[name]; !ok {
+			names = append(names, name)
+		}
+		columns[name] = append(columns[name], builder.dialect.QuoteIdentifier(field.Column))
+	}
+	queries := make([]string, len(names))
+	for i, name := range names {
+		if err := validateIdentifier(name); err != nil {
+			return nil, errors.Wrapf(err, "Invalid index name '%s'.", name)
+		}
+		queries[i] = "CREATE INDEX " + builder.dialect.QuoteIdentifier(name) + " ON " + tableName + " (" +
+			strings.Join(columns[name], ",") + ")"
+	}
+	return queries, nil
+}
+
+// BuildDropTableQuery builds a DROP TABLE query from the passed struct pointer.
+func (builder *QueryBuilder) BuildDropTableQuery(object interface{}) (string, error) {
+	_, tableName, err := builder.getMetadataAndTableName(object)
+	if err != nil {
+		return "", err
+	}
+	return "DROP TABLE " + tableName, nil
+}
+
+// buildColumnDefinition returns the definition of the passed field's column within a CREATE TABLE query, and whether
+// the definition declares the column as the table's primary key.
+func (builder *QueryBuilder) buildColumnDefinition(metadata *Metadata, field *Field) (string, bool, error) {
+	columnType, ok := builder.dialect.ColumnType(field.ValueType(), field.Size)
+	if !ok {
+		return "", false, errors.Errorf("Unable to determine column type of field '%s' (type '%s' is not supported by %s).", field.Name, field.Type, builder.dialect.Name())
+	}
+	definition := builder.dialect.QuoteIdentifier(field.Column) + " " + columnType
+	if field.Nullable && !field.Key && !field.AutoIncrement {
+		definition += " NULL"
+	} else {
+		definition += " NOT NULL"
+	}
+	if field.Default != "" {
+		definition += " DEFAULT " + field.Default
+	}
+	declaresKey := false
+	if field.AutoIncrement {
+		var attribute string
+		attribute, declaresKey = builder.dialect.AutoIncrement()
+		if declaresKey && (len(metadata.Keys) != 1 || metadata.Keys[0] != field) {
+			return "", false, errors.Errorf("Unable to create auto-increment column '%s' (%s requires it to be the only primary key field).", field.Column, builder.dialect.Name())
+		}
+		definition += " " + attribute
+	}
+	if field.Unique {
+		definition += " UNIQUE"
+	}
+	return definition, declaresKey, nil
+}
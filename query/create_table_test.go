@@ -0,0 +1,118 @@
+package query_test
+
+import (
+	. "github.com/dtucker2/database/query"
+
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type account struct {
+	Id        int64          `name:"id" key:"true" type:"auto-increment"`
+	Email     string         `name:"email" size:"320" unique:"true"`
+	Name      string         `name:"name" index:"true"`
+	Nickname  *string        `name:"nickname"`
+	Notes     sql.NullString `name:"notes" size:"1000"`
+	Avatar    []byte         `name:"avatar" nullable:"true"`
+	Balance   float64        `name:"balance" default:"0"`
+	OrgId     int32          `name:"org_id" index:"idx_accounts_team"`
+	TeamId    int32          `name:"team_id" index:"idx_accounts_team"`
+	CreatedAt time.Time      `name:"created_at" type:"created_at"`
+	DeletedAt *time.Time     `name:"deleted_at" type:"deleted_at"`
+}
+
+type objectWithUnsupportedType struct {
+	Id       int               `name:"id"`
+	Settings map[string]string `name:"settings"`
+}
+
+type objectWithInvalidSize struct {
+	Id   int    `name:"id"`
+	Name string `name:"name" size:"large"`
+}
+
+func TestQueryBuilder_BuildCreateTableQuery(t *testing.T) {
+	builder := NewQueryBuilder()
+	query, err := builder.BuildCreateTableQuery(&account{})
+	require.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE `accounts` ("+
+		"`id` BIGINT NOT NULL AUTO_INCREMENT,"+
+		"`email` VARCHAR(320) NOT NULL UNIQUE,"+
+		"`name` VARCHAR(255) NOT NULL,"+
+		"`nickname` VARCHAR(255) NULL,"+
+		"`notes` VARCHAR(1000) NULL,"+
+		"`avatar` LONGBLOB NULL,"+
+		"`balance` DOUBLE NOT NULL DEFAULT 0,"+
+		"`org_id` INT NOT NULL,"+
+		"`team_id` INT NOT NULL,"+
+		"`created_at` TIMESTAMP NOT NULL,"+
+		"`deleted_at` TIMESTAMP NULL,"+
+		"PRIMARY KEY (`id`))", query)
+
+	queries, err := builder.BuildCreateIndexQueries(&account{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"CREATE INDEX `idx_accounts_name` ON `accounts` (`name`)",
+		"CREATE INDEX `idx_accounts_team` ON `accounts` (`org_id`,`team_id`)",
+	}, queries)
+
+	query, err = builder.BuildDropTableQuery(&account{})
+	require.NoError(t, err)
+	assert.Equal(t, "DROP TABLE `accounts`", query)
+
+	query, err = builder.BuildCreateTableQuery(&objectWithCompositeKey{})
+	require.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE `user_roles` (`user_id` BIGINT NOT NULL,`role_id` BIGINT NOT NULL,"+
+		"`note` VARCHAR(255) NOT NULL,PRIMARY KEY (`user_id`,`role_id`))", query)
+	queries, err = builder.BuildCreateIndexQueries(&objectWithCompositeKey{})
+	require.NoError(t, err)
+	assert.Empty(t, queries)
+
+	_, err = builder.BuildCreateTableQuery(&objectWithUnsupportedType{})
+	assert.Error(t, err)
+	_, err = builder.BuildCreateTableQuery(&objectWithInvalidSize{})
+	assert.Error(t, err)
+}
+
+func TestQueryBuilder_BuildCreateTableQuery_Dialects(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		query   string
+	}{
+		{
+			dialect: MySQL,
+			query: "CREATE TABLE `objects` (`id` BIGINT NOT NULL AUTO_INCREMENT,`name` VARCHAR(255) NOT NULL," +
+				"`created_at` TIMESTAMP NULL,`updated_at` TIMESTAMP NULL,PRIMARY KEY (`id`))",
+		},
+		{
+			dialect: PostgreSQL,
+			query: `CREATE TABLE "objects" ("id" BIGINT NOT NULL GENERATED BY DEFAULT AS IDENTITY,` +
+				`"name" VARCHAR(255) NOT NULL,"created_at" TIMESTAMP NULL,"updated_at" TIMESTAMP NULL,PRIMARY KEY ("id"))`,
+		},
+		{
+			dialect: SQLite,
+			query: `CREATE TABLE "objects" ("id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,` +
+				`"name" VARCHAR(255) NOT NULL,"created_at" TIMESTAMP NULL,"updated_at" TIMESTAMP NULL)`,
+		},
+		{
+			dialect: SQLServer,
+			query: `CREATE TABLE [objects] ([id] BIGINT NOT NULL IDENTITY(1,1),[name] NVARCHAR(255) NOT NULL,` +
+				`[created_at] DATETIME2 NULL,[updated_at] DATETIME2 NULL,PRIMARY KEY ([id]))`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.dialect.Name(), func(t *testing.T) {
+			builder := NewQueryBuilder(WithDialect(test.dialect))
+			query, err := builder.BuildCreateTableQuery(&objectWithTags{})
+			require.NoError(t, err)
+			assert.Equal(t, test.query, query)
+		})
+	}
+	// SQLite only supports AUTOINCREMENT on a column which is the whole primary key.
+	_, err := NewQueryBuilder(WithDialect(SQLite)).BuildCreateTableQuery(&objectWithNoKey{})
+	assert.Error(t, err)
+}
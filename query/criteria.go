@@ -0,0 +1,304 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Criteria describes the conditions, ordering and row limits of a SELECT query built by BuildCriteriaQuery.
+// Every method returns a modified copy, so a Criteria can be safely shared and extended.
+type Criteria struct {
+	conditions  []condition
+	orders      []string
+	limit       int
+	offset      int
+	withTrashed bool
+}
+
+type condition struct {
+	or bool
+	// clause is either a string using '?' placeholders, a struct pointer whose non-zero fields must all match or an
+	// inClause.
+	clause interface{}
+	args   []interface{}
+}
+
+// inClause matches rows whose column is equal to one of the condition's arguments.
+type inClause struct {
+	column string
+}
+
+// NewCriteria returns a pointer to a new, empty instance of the Criteria struct.
+func NewCriteria() *Criteria {
+	return &Criteria{}
+}
+
+// Where adds a condition which must be met in addition to any previous conditions. The condition is either a clause
+// using '?' placeholders followed by its arguments, e.g. Where("age > ?", 30), or a struct pointer whose non-zero
+// fields are matched for equality, e.g. Where(&Person{Age: 30}).
+func (criteria *Criteria) Where(clause interface{}, args ...interface{}) *Criteria {
+	return criteria.addCondition(false, clause, args)
+}
+
+// OrWhere adds a condition which may be met instead of the previous conditions. It accepts the same conditions as Where.
+func (criteria *Criteria) OrWhere(clause interface{}, args ...interface{}) *Criteria {
+	return criteria.addCondition(true, clause, args)
+}
+
+// WhereIn adds a condition, which must be met in addition to any previous conditions, matching rows whose column is
+// equal to one of the passed values. No rows match an empty list of values.
+func (criteria *Criteria) WhereIn(column string, values ...interface{}) *Criteria {
+	return criteria.addCondition(false, inClause{column: column}, values)
+}
+
+// OrderBy adds columns to sort by. Each column may be followed by 'ASC' or 'DESC', e.g. OrderBy("age DESC", "name").
+func (criteria *Criteria) OrderBy(columns ...string) *Criteria {
+	clone := criteria.clone()
+	clone.orders = append(clone.orders, columns...)
+	return clone
+}
+
+// Limit restricts the number of rows returned. A limit of zero or less means no limit is applied.
+func (criteria *Criteria) Limit(limit int) *Criteria {
+	clone := criteria.clone()
+	clone.limit = limit
+	return clone
+}
+
+// Offset skips the passed number of rows before returning any.
+func (criteria *Criteria) Offset(offset int) *Criteria {
+	clone := criteria.clone()
+	clone.offset = offset
+	return clone
+}
+
+// WithTrashed includes soft deleted rows, which are otherwise excluded from tables with a deleted_at column.
+func (criteria *Criteria) WithTrashed() *Criteria {
+	clone := criteria.clone()
+	clone.withTrashed = true
+	return clone
+}
+
+func (criteria *Criteria) addCondition(or bool, clause interface{}, args []interface{}) *Criteria {
+	clone := criteria.clone()
+	clone.conditions = append(clone.conditions, condition{
+		or:     or,
+		clause: clause,
+		args:   args,
+	})
+	return clone
+}
+
+func (criteria *Criteria) clone() *Criteria {
+	if criteria == nil {
+		return &Criteria{}
+	}
+	return &Criteria{
+		conditions:  append([]condition(nil), criteria.conditions...),
+		orders:      append([]string(nil), criteria.orders...),
+		limit:       criteria.limit,
+		offset:      criteria.offset,
+		withTrashed: criteria.withTrashed,
+	}
+}
+
+// BuildCriteriaQuery constructs and returns a SELECT query and arguments for the rows of the passed object's (struct
+// pointer) table matching the passed criteria. Soft deleted rows are excluded unless the criteria or builder include them.
+func (builder *QueryBuilder) BuildCriteriaQuery(object interface{}, criteria *Criteria) (string, []interface{}, error) {
+	metadata, tableName, err := builder.getMetadataAndTableName(object)
+	if err != nil {
+		return "", nil, err
+	}
+	if criteria == nil {
+		criteria = NewCriteria()
+	}
+	parts := []string{
+		"SELECT",
+		strings.Join(builder.getColumnNames(metadata), ","),
+		"FROM",
+		tableName,
+	}
+	where, args, err := builder.buildWhere(metadata, criteria)
+	if err != nil {
+		return "", nil, err
+	}
+	if where != "" {
+		parts = append(parts, "WHERE", where)
+	}
+	limit := builder.dialect.Limit(criteria.limit, criteria.offset)
+	if len(criteria.orders) > 0 {
+		orders, err := builder.buildOrders(metadata, criteria.orders)
+		if err != nil {
+			return "", nil, err
+		}
+		parts = append(parts, "ORDER BY", orders)
+	} else if limit != "" && builder.dialect.RequiresOrderBy() {
+		parts = append(parts, "ORDER BY", builder.buildDefaultOrder(metadata))
+	}
+	if limit != "" {
+		parts = append(parts, limit)
+	}
+	return strings.Join(parts, " "), args, nil
+}
+
+// BuildCountQuery constructs and returns a SELECT COUNT(*) query and arguments for the rows of the passed object's
+// (struct pointer) table matching the passed criteria. The criteria's ordering and row limits are ignored.
+func (builder *QueryBuilder) BuildCountQuery(object interface{}, criteria *Criteria) (string, []interface{}, error) {
+	metadata, tableName, err := builder.getMetadataAndTableName(object)
+	if err != nil {
+		return "", nil, err
+	}
+	if criteria == nil {
+		criteria = NewCriteria()
+	}
+	parts := []string{
+		"SELECT COUNT(*) FROM",
+		tableName,
+	}
+	where, args, err := builder.buildWhere(metadata, criteria)
+	if err != nil {
+		return "", nil, err
+	}
+	if where != "" {
+		parts = append(parts, "WHERE", where)
+	}
+	return strings.Join(parts, " "), args, nil
+}
+
+// buildDefaultOrder returns the ordering used when rows are limited without an explicit order on databases requiring
+// one. Rows are ordered by primary key so that pages are stable, or arbitrarily when the table has no primary key.
+func (builder *QueryBuilder) buildDefaultOrder(metadata *Metadata) string {
+	if len(metadata.Keys) == 0 {
+		return "(SELECT NULL)"
+	}
+	keys := make([]string, len(metadata.Keys))
+	for i, field := range metadata.Keys {
+		keys[i] = builder.dialect.QuoteIdentifier(field.Column)
+	}
+	return strings.Join(keys, ",")
+}
+
+// buildWhere combines the criteria's conditions with the exclusion of soft deleted rows.
+func (builder *QueryBuilder) buildWhere(metadata *Metadata, criteria *Criteria) (string, []interface{}, error) {
+	where, args, err := builder.buildConditions(criteria.conditions, 1)
+	if err != nil {
+		return "", nil, err
+	}
+	if !builder.softDeletes(metadata) || criteria.withTrashed {
+		return where, args, nil
+	}
+	if where == "" {
+		return builder.buildNotDeletedCondition(metadata), args, nil
+	}
+	// The conditions are parenthesised as they may be joined using OR.
+	return "(" + where + ") AND " + builder.buildNotDeletedCondition(metadata), args, nil
+}
+
+// buildConditions joins the passed conditions from left to right, so that Where(a).OrWhere(b).Where(c) matches rows
+// meeting (a OR b) AND c. The conditions built so far are parenthesised whenever the connector changes.
+func (builder *QueryBuilder) buildConditions(conditions []condition, start int) (string, []interface{}, error) {
+	where, single := "", ""
+	count, or := 0, false
+	args := make([]interface{}, 0)
+	for _, condition := range conditions {
+		clause, clauseArgs, err := builder.buildCondition(condition, start+len(args))
+		if err != nil {
+			return "", nil, err
+		}
+		if clause == "" {
+			continue
+		}
+		connector := " AND "
+		if condition.or {
+			connector = " OR "
+		}
+		switch {
+		case count == 0:
+			single, where = clause, "("+clause+")"
+		case count > 1 && condition.or != or:
+			where = "(" + where + ")" + connector + "(" + clause + ")"
+		default:
+			where += connector + "(" + clause + ")"
+		}
+		if count > 0 {
+			or = condition.or
+		}
+		count++
+		args = append(args, clauseArgs...)
+	}
+	// A single condition needs no parentheses.
+	if count == 1 {
+		return single, args, nil
+	}
+	return where, args, nil
+}
+
+func (builder *QueryBuilder) buildCondition(condition condition, start int) (string, []interface{}, error) {
+	switch clause := condition.clause.(type) {
+	case string:
+		return builder.rebind(clause, start), condition.args, nil
+	case inClause:
+		if err := validateIdentifier(clause.column); err != nil {
+			return "", nil, err
+		}
+		if len(condition.args) == 0 {
+			return "1=0", nil, nil
+		}
+		return builder.dialect.QuoteIdentifier(clause.column) + " IN (" + builder.buildPlaceholders(start, len(condition.args)) + ")", condition.args, nil
+	default:
+		val := reflect.ValueOf(clause)
+		if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+			return "", nil, errors.Errorf("Unable to use condition of type '%T' (expected a string or struct pointer).", clause)
+		}
+		return builder.buildStructCondition(clause, start)
+	}
+}
+
+// buildStructCondition matches every non-zero field of the passed object (struct pointer) for equality.
+func (builder *QueryBuilder) buildStructCondition(object interface{}, start int) (string, []interface{}, error) {
+	metadata, err := GetMetadata(reflect.TypeOf(object))
+	if err != nil {
+		return "", nil, err
+	}
+	val := reflect.ValueOf(object).Elem()
+	clauses := make([]string, 0)
+	args := make([]interface{}, 0)
+	for _, field := range metadata.Fields {
+		value := field.Value(val)
+		if value.IsZero() {
+			continue
+		}
+		clauses = append(clauses, builder.dialect.QuoteIdentifier(field.Column)+"="+builder.dialect.Placeholder(start+len(args)))
+		args = append(args, value.Interface())
+	}
+	// A struct without any non-zero fields matches every row, rather than being dropped and changing how the
+	// conditions around it are joined.
+	if len(clauses) == 0 {
+		return "1=1", args, nil
+	}
+	return strings.Join(clauses, " AND "), args, nil
+}
+
+func (builder *QueryBuilder) buildOrders(metadata *Metadata, orders []string) (string, error) {
+	clauses := make([]string, len(orders))
+	for i, order := range orders {
+		parts := strings.Fields(order)
+		if len(parts) == 0 || len(parts) > 2 {
+			return "", errors.Errorf("Unable to order by '%s' (expected a column optionally followed by ASC or DESC).", order)
+		}
+		if _, ok := metadata.Column(parts[0]); !ok {
+			return "", errors.Errorf("Unable to order by '%s' (no such column).", parts[0])
+		}
+		clauses[i] = builder.dialect.QuoteIdentifier(parts[0])
+		if len(parts) == 2 {
+			direction := strings.ToUpper(parts[1])
+			if direction != "ASC" && direction != "DESC" {
+				return "", errors.Errorf("Unable to order by '%s' (expected a column optionally followed by ASC or DESC).", order)
+			}
+			clauses[i] += " " + direction
+		}
+	}
+	return strings.Join(clauses, ","), nil
+}
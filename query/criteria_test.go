@@ -0,0 +1,112 @@
+package query_test
+
+import (
+	. "github.com/dtucker2/database/query"
+
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryBuilder_BuildCriteriaQuery(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		query, args, err := builder.BuildCriteriaQuery(&objectWithTags{}, NewCriteria())
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT `id`,`name`,`created_at`,`updated_at` FROM `objects`", query)
+		assert.Empty(t, args)
+	})
+	t.Run("conditions", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		criteria := NewCriteria().
+			Where("id > ?", 1).
+			Where("name <> ?", "Test Object").
+			OrWhere("id = ?", 0)
+		query, args, err := builder.BuildCriteriaQuery(&objectWithTags{}, criteria)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT `id`,`name`,`created_at`,`updated_at` FROM `objects` WHERE ((id > ?) AND (name <> ?)) OR (id = ?)", query)
+		assert.Equal(t, []interface{}{1, "Test Object", 0}, args)
+	})
+	t.Run("grouping", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		criteria := NewCriteria().
+			Where("id > ?", 1).
+			OrWhere("name = ?", "Test Object").
+			Where("id < ?", 10).
+			Where("id <> ?", 5)
+		query, args, err := builder.BuildCriteriaQuery(&objectWithTags{}, criteria)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT `id`,`name`,`created_at`,`updated_at` FROM `objects` WHERE ((id > ?) OR (name = ?)) AND (id < ?) AND (id <> ?)", query)
+		assert.Equal(t, []interface{}{1, "Test Object", 10, 5}, args)
+	})
+	t.Run("struct condition", func(t *testing.T) {
+		builder := NewQueryBuilder(WithDialect(PostgreSQL))
+		criteria := NewCriteria().
+			Where("id > ?", 1).
+			Where(&objectWithTags{Name: "Test Object"})
+		query, args, err := builder.BuildCriteriaQuery(&objectWithTags{}, criteria)
+		require.NoError(t, err)
+		assert.Equal(t, `SELECT "id","name","created_at","updated_at" FROM "objects" WHERE (id > $1) AND ("name"=$2)`, query)
+		assert.Equal(t, []interface{}{1, "Test Object"}, args)
+		query, args, err = builder.BuildCriteriaQuery(&objectWithTags{}, NewCriteria().Where(&objectWithTags{}).OrWhere("id = ?", 1))
+		require.NoError(t, err)
+		assert.Equal(t, `SELECT "id","name","created_at","updated_at" FROM "objects" WHERE (1=1) OR (id = $1)`, query)
+		assert.Equal(t, []interface{}{1}, args)
+	})
+	t.Run("order and limit", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		criteria := NewCriteria().
+			OrderBy("name desc", "id").
+			Limit(10).
+			Offset(20)
+		query, args, err := builder.BuildCriteriaQuery(&objectWithTags{}, criteria)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT `id`,`name`,`created_at`,`updated_at` FROM `objects` ORDER BY `name` DESC,`id` LIMIT 10 OFFSET 20", query)
+		assert.Empty(t, args)
+	})
+	t.Run("limit without order on sql server", func(t *testing.T) {
+		builder := NewQueryBuilder(WithDialect(SQLServer))
+		query, _, err := builder.BuildCriteriaQuery(&objectWithTags{}, NewCriteria().Limit(10).Offset(20))
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT [id],[name],[created_at],[updated_at] FROM [objects] ORDER BY [id] OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY", query)
+		query, _, err = builder.BuildCriteriaQuery(&objectWithCompositeKey{}, NewCriteria().Offset(5))
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT [user_id],[role_id],[note] FROM [user_roles] ORDER BY [user_id],[role_id] OFFSET 5 ROWS", query)
+		query, _, err = builder.BuildCriteriaQuery(&objectWithOnlyKeys{}, NewCriteria().Limit(1))
+		require.NoError(t, err)
+		assert.Contains(t, query, "ORDER BY [user_id],[role_id] OFFSET 0 ROWS FETCH NEXT 1 ROWS ONLY")
+		query, _, err = builder.BuildCriteriaQuery(&objectWithNoKey{}, NewCriteria().Limit(1))
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT [an_id],[name],[created_at],[updated_at] FROM [objects] ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT 1 ROWS ONLY", query)
+		query, _, err = builder.BuildCriteriaQuery(&objectWithTags{}, NewCriteria().OrderBy("name").Limit(10))
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT [id],[name],[created_at],[updated_at] FROM [objects] ORDER BY [name] OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY", query)
+		query, _, err = builder.BuildCriteriaQuery(&objectWithTags{}, NewCriteria())
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT [id],[name],[created_at],[updated_at] FROM [objects]", query)
+	})
+	t.Run("invalid order", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		_, _, err := builder.BuildCriteriaQuery(&objectWithTags{}, NewCriteria().OrderBy("missing"))
+		require.Error(t, err)
+		_, _, err = builder.BuildCriteriaQuery(&objectWithTags{}, NewCriteria().OrderBy("name; DROP TABLE objects"))
+		require.Error(t, err)
+		_, _, err = builder.BuildCriteriaQuery(&objectWithTags{}, NewCriteria().OrderBy("name sideways"))
+		require.Error(t, err)
+	})
+	t.Run("invalid condition", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		_, _, err := builder.BuildCriteriaQuery(&objectWithTags{}, NewCriteria().Where(1))
+		require.Error(t, err)
+	})
+	t.Run("immutable", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		base := NewCriteria().Where("id > ?", 1)
+		base.Where("id < ?", 10)
+		query, args, err := builder.BuildCriteriaQuery(&objectWithTags{}, base)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT `id`,`name`,`created_at`,`updated_at` FROM `objects` WHERE id > ?", query)
+		assert.Equal(t, []interface{}{1}, args)
+	})
+}
@@ -0,0 +1,476 @@
+package query
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultStringSize is the length of string columns without a 'size' tag.
+const defaultStringSize = 255
+
+// Dialect describes the SQL syntax differences between database engines that the QueryBuilder needs to account for.
+type Dialect interface {
+	// Name returns the name of the database engine.
+	Name() string
+	// Placeholder returns the bind parameter placeholder for the argument at the passed (1-based) position.
+	Placeholder(position int) string
+	// QuoteIdentifier returns the passed table or column name quoted for use in a query.
+	QuoteIdentifier(name string) string
+	// Limit returns the clause used to restrict the number of rows returned by a query.
+	// A limit of zero or less means no limit is applied.
+	Limit(limit, offset int) string
+	// RequiresOrderBy reports whether the clause returned by Limit is only valid following an ORDER BY clause.
+	RequiresOrderBy() bool
+	// SupportsReturning reports whether INSERT queries can return generated values using a RETURNING clause.
+	SupportsReturning() bool
+	// CountsChangedRows reports whether UPDATE queries report the number of rows changed rather than matched, so that
+	// an update writing a row's current values affects no rows.
+	CountsChangedRows() bool
+	// MaxParameters returns the maximum number of bind parameters allowed in a single query.
+	MaxParameters() int
+	// InsertedValue returns an expression referring to the value that an upsert attempted to insert into the passed
+	// (quoted) column, for use in the assignments passed to OnConflictUpdate.
+	InsertedValue(column string) string
+	// OnConflictUpdate returns the clause appended to an INSERT query to apply the passed assignments when a row with
+	// the same (quoted) key columns already exists. It returns false if the database does not support upserts.
+	OnConflictUpdate(keyColumns []string, assignments []string) (string, bool)
+	// ReportExistingId returns the assignment passed to OnConflictUpdate which makes an upsert updating an existing row
+	// report the row's (quoted) auto-increment column as the inserted id. It returns false if the dialect returns the
+	// column using a RETURNING clause instead.
+	ReportExistingId(column string) (string, bool)
+	// ColumnType returns the column type storing values of the passed Go type, which is never a pointer or sql.Null
+	// type. The size is the length of string and []byte columns, zero meaning the dialect's default. It returns false
+	// if the type has no corresponding column type.
+	ColumnType(typ reflect.Type, size int) (string, bool)
+	// AutoIncrement returns the attribute appended to a column definition to generate its values. It returns true if
+	// the attribute also declares the column as the table's primary key.
+	AutoIncrement() (string, bool)
+	// TableColumns returns a query, and its arguments, selecting the name, data type and nullability of each column of
+	// the passed table in order. An empty schema means the connection's current schema. No rows are selected if the
+	// table does not exist.
+	TableColumns(schema, table string) (string, []interface{})
+}
+
+var (
+	// MySQL is the Dialect for MySQL and MariaDB databases. It is used when no Dialect is specified.
+	MySQL Dialect = mysqlDialect{}
+	// PostgreSQL is the Dialect for PostgreSQL databases.
+	PostgreSQL Dialect = postgresDialect{}
+	// SQLite is the Dialect for SQLite databases.
+	SQLite Dialect = sqliteDialect{}
+	// SQLServer is the Dialect for Microsoft SQL Server databases.
+	SQLServer Dialect = sqlServerDialect{}
+)
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string {
+	return "mysql"
+}
+
+func (mysqlDialect) Placeholder(position int) string {
+	return "?"
+}
+
+func (mysqlDialect) QuoteIdentifier(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+func (mysqlDialect) Limit(limit, offset int) string {
+	// MySQL requires a LIMIT whenever an OFFSET is given, the documented workaround is the largest unsigned value.
+	if limit <= 0 && offset > 0 {
+		return "LIMIT 18446744073709551615 OFFSET " + strconv.Itoa(offset)
+	}
+	return limitOffset(limit, offset)
+}
+
+func (mysqlDialect) RequiresOrderBy() bool {
+	return false
+}
+
+func (mysqlDialect) SupportsReturning() bool {
+	return false
+}
+
+func (mysqlDialect) CountsChangedRows() bool {
+	// Matched rows are only counted when the client sets CLIENT_FOUND_ROWS, which the connection does not reveal.
+	return true
+}
+
+func (mysqlDialect) MaxParameters() int {
+	return 65535
+}
+
+func (mysqlDialect) InsertedValue(column string) string {
+	return "VALUES(" + column + ")"
+}
+
+func (mysqlDialect) OnConflictUpdate(keyColumns []string, assignments []string) (string, bool) {
+	if len(assignments) == 0 {
+		// MySQL requires at least one assignment, assigning a key column to itself leaves the row untouched.
+		assignments = []string{keyColumns[0] + "=" + keyColumns[0]}
+	}
+	return "ON DUPLICATE KEY UPDATE " + strings.Join(assignments, ","), true
+}
+
+func (mysqlDialect) ReportExistingId(column string) (string, bool) {
+	// LAST_INSERT_ID is otherwise not meaningful once a row is updated instead of inserted.
+	return column + "=LAST_INSERT_ID(" + column + ")", true
+}
+
+var mysqlColumnTypes = map[reflect.Kind]string{
+	reflect.Bool:    "BOOLEAN",
+	reflect.Int:     "BIGINT",
+	reflect.Int8:    "TINYINT",
+	reflect.Int16:   "SMALLINT",
+	reflect.Int32:   "INT",
+	reflect.Int64:   "BIGINT",
+	reflect.Uint:    "BIGINT UNSIGNED",
+	reflect.Uint8:   "TINYINT UNSIGNED",
+	reflect.Uint16:  "SMALLINT UNSIGNED",
+	reflect.Uint32:  "INT UNSIGNED",
+	reflect.Uint64:  "BIGINT UNSIGNED",
+	reflect.Float32: "FLOAT",
+	reflect.Float64: "DOUBLE",
+}
+
+func (mysqlDialect) ColumnType(typ reflect.Type, size int) (string, bool) {
+	switch {
+	case typ == timeType:
+		return "TIMESTAMP", true
+	case isBytesType(typ):
+		// VARBINARY is limited by the 65535 byte row size.
+		if size <= 0 || size > 65535 {
+			return "LONGBLOB", true
+		}
+		return "VARBINARY(" + strconv.Itoa(size) + ")", true
+	case typ.Kind() == reflect.String:
+		// A VARCHAR of more than 16383 characters may exceed the row size using the utf8mb4 character set.
+		if size > 16383 {
+			return "LONGTEXT", true
+		}
+		return "VARCHAR(" + strconv.Itoa(stringSize(size)) + ")", true
+	}
+	columnType, ok := mysqlColumnTypes[typ.Kind()]
+	return columnType, ok
+}
+
+func (mysqlDialect) AutoIncrement() (string, bool) {
+	return "AUTO_INCREMENT", false
+}
+
+func (mysqlDialect) TableColumns(schema, table string) (string, []interface{}) {
+	return informationSchemaColumns("COALESCE(NULLIF(?, ''), DATABASE())", "?"), []interface{}{schema, table}
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string {
+	return "postgres"
+}
+
+func (postgresDialect) Placeholder(position int) string {
+	return "$" + strconv.Itoa(position)
+}
+
+func (postgresDialect) QuoteIdentifier(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+func (postgresDialect) Limit(limit, offset int) string {
+	return limitOffset(limit, offset)
+}
+
+func (postgresDialect) RequiresOrderBy() bool {
+	return false
+}
+
+func (postgresDialect) SupportsReturning() bool {
+	return true
+}
+
+func (postgresDialect) CountsChangedRows() bool {
+	return false
+}
+
+func (postgresDialect) MaxParameters() int {
+	return 65535
+}
+
+func (postgresDialect) InsertedValue(column string) string {
+	return "EXCLUDED." + column
+}
+
+func (postgresDialect) OnConflictUpdate(keyColumns []string, assignments []string) (string, bool) {
+	return onConflict(keyColumns, assignments), true
+}
+
+func (postgresDialect) ReportExistingId(column string) (string, bool) {
+	return "", false
+}
+
+var postgresColumnTypes = map[reflect.Kind]string{
+	reflect.Bool:    "BOOLEAN",
+	reflect.Int:     "BIGINT",
+	reflect.Int8:    "SMALLINT",
+	reflect.Int16:   "SMALLINT",
+	reflect.Int32:   "INTEGER",
+	reflect.Int64:   "BIGINT",
+	reflect.Uint:    "NUMERIC(20)",
+	reflect.Uint8:   "SMALLINT",
+	reflect.Uint16:  "INTEGER",
+	reflect.Uint32:  "BIGINT",
+	reflect.Uint64:  "NUMERIC(20)",
+	reflect.Float32: "REAL",
+	reflect.Float64: "DOUBLE PRECISION",
+}
+
+func (postgresDialect) ColumnType(typ reflect.Type, size int) (string, bool) {
+	switch {
+	case typ == timeType:
+		return "TIMESTAMP", true
+	case isBytesType(typ):
+		return "BYTEA", true
+	case typ.Kind() == reflect.String:
+		if size > 10485760 {
+			return "TEXT", true
+		}
+		return "VARCHAR(" + strconv.Itoa(stringSize(size)) + ")", true
+	}
+	columnType, ok := postgresColumnTypes[typ.Kind()]
+	return columnType, ok
+}
+
+func (postgresDialect) AutoIncrement() (string, bool) {
+	return "GENERATED BY DEFAULT AS IDENTITY", false
+}
+
+func (postgresDialect) TableColumns(schema, table string) (string, []interface{}) {
+	return informationSchemaColumns("COALESCE(NULLIF($1, ''), current_schema())", "$2"), []interface{}{schema, table}
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string {
+	return "sqlite3"
+}
+
+func (sqliteDialect) Placeholder(position int) string {
+	return "?"
+}
+
+func (sqliteDialect) QuoteIdentifier(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+func (sqliteDialect) Limit(limit, offset int) string {
+	// SQLite requires a LIMIT whenever an OFFSET is given; -1 means no limit.
+	if limit <= 0 && offset > 0 {
+		return "LIMIT -1 OFFSET " + strconv.Itoa(offset)
+	}
+	return limitOffset(limit, offset)
+}
+
+func (sqliteDialect) RequiresOrderBy() bool {
+	return false
+}
+
+func (sqliteDialect) SupportsReturning() bool {
+	return true
+}
+
+func (sqliteDialect) CountsChangedRows() bool {
+	return false
+}
+
+func (sqliteDialect) MaxParameters() int {
+	// SQLite has allowed 32766 parameters by default since 3.32.0, older versions only allow 999.
+	return 32766
+}
+
+func (sqliteDialect) InsertedValue(column string) string {
+	return "excluded." + column
+}
+
+func (sqliteDialect) OnConflictUpdate(keyColumns []string, assignments []string) (string, bool) {
+	return onConflict(keyColumns, assignments), true
+}
+
+func (sqliteDialect) ReportExistingId(column string) (string, bool) {
+	return "", false
+}
+
+func (sqliteDialect) ColumnType(typ reflect.Type, size int) (string, bool) {
+	// SQLite only enforces type affinity, the declared types document the column.
+	switch {
+	case typ == timeType:
+		return "TIMESTAMP", true
+	case isBytesType(typ):
+		return "BLOB", true
+	case typ.Kind() == reflect.String:
+		return "VARCHAR(" + strconv.Itoa(stringSize(size)) + ")", true
+	case typ.Kind() == reflect.Bool:
+		return "BOOLEAN", true
+	case typ.Kind() >= reflect.Int && typ.Kind() <= reflect.Uint64:
+		// Only a column declared exactly as 'INTEGER PRIMARY KEY' aliases the rowid.
+		return "INTEGER", true
+	case typ.Kind() == reflect.Float32 || typ.Kind() == reflect.Float64:
+		return "REAL", true
+	}
+	return "", false
+}
+
+// AutoIncrement declares the primary key as SQLite only allows AUTOINCREMENT on an 'INTEGER PRIMARY KEY' column.
+func (sqliteDialect) AutoIncrement() (string, bool) {
+	return "PRIMARY KEY AUTOINCREMENT", true
+}
+
+// TableColumns uses the table-valued form of PRAGMA table_info, available since SQLite 3.16.0. Primary key columns
+// are reported as NOT NULL, as an 'INTEGER PRIMARY KEY' column is never NULL without being declared NOT NULL.
+func (sqliteDialect) TableColumns(schema, table string) (string, []interface{}) {
+	query := `SELECT name, type, "notnull" = 0 AND pk = 0 FROM pragma_table_info(?, COALESCE(NULLIF(?, ''), 'main')) ` +
+		`ORDER BY cid`
+	return query, []interface{}{table, schema}
+}
+
+type sqlServerDialect struct{}
+
+func (sqlServerDialect) Name() string {
+	return "sqlserver"
+}
+
+func (sqlServerDialect) Placeholder(position int) string {
+	return "@p" + strconv.Itoa(position)
+}
+
+func (sqlServerDialect) QuoteIdentifier(name string) string {
+	return "[" + strings.Replace(name, "]", "]]", -1) + "]"
+}
+
+func (sqlServerDialect) Limit(limit, offset int) string {
+	// SQL Server only supports OFFSET/FETCH following an ORDER BY clause.
+	if limit <= 0 && offset <= 0 {
+		return ""
+	}
+	clause := "OFFSET " + strconv.Itoa(offset) + " ROWS"
+	if limit > 0 {
+		clause += " FETCH NEXT " + strconv.Itoa(limit) + " ROWS ONLY"
+	}
+	return clause
+}
+
+func (sqlServerDialect) RequiresOrderBy() bool {
+	return true
+}
+
+func (sqlServerDialect) SupportsReturning() bool {
+	return false
+}
+
+func (sqlServerDialect) CountsChangedRows() bool {
+	return false
+}
+
+func (sqlServerDialect) MaxParameters() int {
+	return 2100
+}
+
+func (sqlServerDialect) InsertedValue(column string) string {
+	return ""
+}
+
+// OnConflictUpdate is not supported as SQL Server requires a MERGE statement to upsert.
+func (sqlServerDialect) OnConflictUpdate(keyColumns []string, assignments []string) (string, bool) {
+	return "", false
+}
+
+func (sqlServerDialect) ReportExistingId(column string) (string, bool) {
+	return "", false
+}
+
+var sqlServerColumnTypes = map[reflect.Kind]string{
+	reflect.Bool:    "BIT",
+	reflect.Int:     "BIGINT",
+	reflect.Int8:    "SMALLINT",
+	reflect.Int16:   "SMALLINT",
+	reflect.Int32:   "INT",
+	reflect.Int64:   "BIGINT",
+	reflect.Uint:    "DECIMAL(20)",
+	reflect.Uint8:   "TINYINT",
+	reflect.Uint16:  "INT",
+	reflect.Uint32:  "BIGINT",
+	reflect.Uint64:  "DECIMAL(20)",
+	reflect.Float32: "REAL",
+	reflect.Float64: "FLOAT",
+}
+
+func (sqlServerDialect) ColumnType(typ reflect.Type, size int) (string, bool) {
+	switch {
+	case typ == timeType:
+		// SQL Server's TIMESTAMP is a row version rather than a date and time.
+		return "DATETIME2", true
+	case isBytesType(typ):
+		if size <= 0 || size > 8000 {
+			return "VARBINARY(MAX)", true
+		}
+		return "VARBINARY(" + strconv.Itoa(size) + ")", true
+	case typ.Kind() == reflect.String:
+		if size > 4000 {
+			return "NVARCHAR(MAX)", true
+		}
+		return "NVARCHAR(" + strconv.Itoa(stringSize(size)) + ")", true
+	}
+	columnType, ok := sqlServerColumnTypes[typ.Kind()]
+	return columnType, ok
+}
+
+func (sqlServerDialect) AutoIncrement() (string, bool) {
+	return "IDENTITY(1,1)", false
+}
+
+func (sqlServerDialect) TableColumns(schema, table string) (string, []interface{}) {
+	return informationSchemaColumns("COALESCE(NULLIF(@p1, ''), SCHEMA_NAME())", "@p2"), []interface{}{schema, table}
+}
+
+// informationSchemaColumns builds the standard SQL query selecting a table's columns from information_schema, used by
+// every dialect except SQLite.
+func informationSchemaColumns(schema, table string) string {
+	return "SELECT column_name, data_type, CASE WHEN is_nullable = 'YES' THEN 1 ELSE 0 END " +
+		"FROM information_schema.columns WHERE table_schema = " + schema + " AND table_name = " + table +
+		" ORDER BY ordinal_position"
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func isBytesType(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8
+}
+
+func stringSize(size int) int {
+	if size <= 0 {
+		return defaultStringSize
+	}
+	return size
+}
+
+func limitOffset(limit, offset int) string {
+	clauses := make([]string, 0)
+	if limit > 0 {
+		clauses = append(clauses, "LIMIT "+strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		clauses = append(clauses, "OFFSET "+strconv.Itoa(offset))
+	}
+	return strings.Join(clauses, " ")
+}
+
+// onConflict builds the standard SQL 'ON CONFLICT' clause used by PostgreSQL and SQLite.
+func onConflict(keyColumns []string, assignments []string) string {
+	clause := "ON CONFLICT (" + strings.Join(keyColumns, ",") + ")"
+	if len(assignments) == 0 {
+		return clause + " DO NOTHING"
+	}
+	return clause + " DO UPDATE SET " + strings.Join(assignments, ",")
+}
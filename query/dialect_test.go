@@ -0,0 +1,95 @@
+package query_test
+
+import (
+	. "github.com/dtucker2/database/query"
+
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialect_Statements(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		insert  string
+		update  string
+		delete  string
+		sel     string
+	}{
+		{
+			dialect: MySQL,
+			insert:  "INSERT INTO `objects` (`name`,`created_at`) VALUES (?,?)",
+			update:  "UPDATE `objects` SET `name`=?,`updated_at`=? WHERE `id`=?",
+			delete:  "DELETE FROM `objects` WHERE `id`=?",
+			sel:     "SELECT `id`,`name`,`created_at`,`updated_at` FROM `objects` WHERE `id`=?",
+		},
+		{
+			dialect: PostgreSQL,
+			insert:  `INSERT INTO "objects" ("name","created_at") VALUES ($1,$2) RETURNING "id"`,
+			update:  `UPDATE "objects" SET "name"=$1,"updated_at"=$2 WHERE "id"=$3`,
+			delete:  `DELETE FROM "objects" WHERE "id"=$1`,
+			sel:     `SELECT "id","name","created_at","updated_at" FROM "objects" WHERE "id"=$1`,
+		},
+		{
+			dialect: SQLite,
+			insert:  `INSERT INTO "objects" ("name","created_at") VALUES (?,?) RETURNING "id"`,
+			update:  `UPDATE "objects" SET "name"=?,"updated_at"=? WHERE "id"=?`,
+			delete:  `DELETE FROM "objects" WHERE "id"=?`,
+			sel:     `SELECT "id","name","created_at","updated_at" FROM "objects" WHERE "id"=?`,
+		},
+		{
+			dialect: SQLServer,
+			insert:  `INSERT INTO [objects] ([name],[created_at]) VALUES (@p1,@p2)`,
+			update:  `UPDATE [objects] SET [name]=@p1,[updated_at]=@p2 WHERE [id]=@p3`,
+			delete:  `DELETE FROM [objects] WHERE [id]=@p1`,
+			sel:     `SELECT [id],[name],[created_at],[updated_at] FROM [objects] WHERE [id]=@p1`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.dialect.Name(), func(t *testing.T) {
+			obj := objectWithTags{
+				Id:   1,
+				Name: "Test Object",
+			}
+			builder := NewQueryBuilder(WithDialect(test.dialect))
+			assert.Equal(t, test.dialect, builder.Dialect())
+			query, _, err := builder.BuildInsertQuery(&obj)
+			require.NoError(t, err)
+			assert.Equal(t, test.insert, query)
+			query, _, err = builder.BuildUpdateQuery(&obj)
+			require.NoError(t, err)
+			assert.Equal(t, test.update, query)
+			query, _, err = builder.BuildDeleteQuery(&obj)
+			require.NoError(t, err)
+			assert.Equal(t, test.delete, query)
+			query, _, err = builder.BuildSelectQuery(&obj)
+			require.NoError(t, err)
+			assert.Equal(t, test.sel, query)
+		})
+	}
+}
+
+func TestDialect_QuoteIdentifier(t *testing.T) {
+	assert.Equal(t, "`order`", MySQL.QuoteIdentifier("order"))
+	assert.Equal(t, "`a``b`", MySQL.QuoteIdentifier("a`b"))
+	assert.Equal(t, `"order"`, PostgreSQL.QuoteIdentifier("order"))
+	assert.Equal(t, `"a""b"`, PostgreSQL.QuoteIdentifier(`a"b`))
+	assert.Equal(t, `"order"`, SQLite.QuoteIdentifier("order"))
+	assert.Equal(t, "[order]", SQLServer.QuoteIdentifier("order"))
+	assert.Equal(t, "[a]]b]", SQLServer.QuoteIdentifier("a]b"))
+}
+
+func TestDialect_Limit(t *testing.T) {
+	assert.Equal(t, "", MySQL.Limit(0, 0))
+	assert.Equal(t, "LIMIT 10", MySQL.Limit(10, 0))
+	assert.Equal(t, "LIMIT 10 OFFSET 20", MySQL.Limit(10, 20))
+	assert.Equal(t, "LIMIT 18446744073709551615 OFFSET 20", MySQL.Limit(0, 20))
+	assert.Equal(t, "LIMIT 10 OFFSET 20", PostgreSQL.Limit(10, 20))
+	assert.Equal(t, "OFFSET 20", PostgreSQL.Limit(0, 20))
+	assert.Equal(t, "LIMIT 10 OFFSET 20", SQLite.Limit(10, 20))
+	assert.Equal(t, "LIMIT -1 OFFSET 20", SQLite.Limit(0, 20))
+	assert.Equal(t, "", SQLServer.Limit(0, 0))
+	assert.Equal(t, "OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY", SQLServer.Limit(10, 20))
+	assert.Equal(t, "OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY", SQLServer.Limit(10, 0))
+}
@@ -0,0 +1,11 @@
+package query
+
+import (
+	"sync"
+)
+
+// ClearMetadataCache discards the Metadata cached by GetMetadata, so that benchmarks can compare building it every
+// time against reading it from the cache.
+func ClearMetadataCache() {
+	metadataCache = sync.Map{}
+}
@@ -0,0 +1,137 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultMaxQuerySize = 4 << 20
+	// maxRowsPerInsert is the largest number of rows accepted by a single SQL Server VALUES list, and a sensible limit
+	// for other databases.
+	maxRowsPerInsert = 1000
+)
+
+// InsertBatch is a single multi-row INSERT query built by BuildInsertManyQueries.
+type InsertBatch struct {
+	// Query is the INSERT query.
+	Query string
+	// Args are the arguments of the query.
+	Args []interface{}
+	// Offset is the index of the first object inserted by the query.
+	Offset int
+	// Count is the number of objects inserted by the query.
+	Count int
+}
+
+// BuildInsertManyQueries constructs and returns multi-row INSERT queries and arguments from the passed objects (a slice,
+// or pointer to a slice, of structs or struct pointers). The objects are split across as few queries as possible while
+// keeping within the dialect's parameter limit and the builder's maximum query size. When the dialect supports it, a
+// RETURNING clause is appended for the auto-increment column.
+func (builder *QueryBuilder) BuildInsertManyQueries(objects interface{}) ([]InsertBatch, error) {
+	slice, err := GetObjectSlice(objects)
+	if err != nil {
+		return nil, err
+	}
+	if slice.Len() == 0 {
+		return nil, nil
+	}
+	first := ObjectAt(slice, 0)
+	metadata, tableName, err := builder.getMetadataAndTableName(first)
+	if err != nil {
+		return nil, err
+	}
+	columnNames, _ := builder.getColumnNamesAndValues(metadata, first, true)
+	if len(columnNames) == 0 {
+		return nil, errors.New("Unable to build insert query (struct has no columns to insert).")
+	}
+	prefix := strings.Join([]string{
+		"INSERT INTO",
+		tableName,
+		"(" + strings.Join(columnNames, ",") + ")",
+		"VALUES",
+	}, " ")
+	suffix := ""
+	if metadata.AutoIncrement != nil && builder.dialect.SupportsReturning() {
+		suffix = " RETURNING " + builder.dialect.QuoteIdentifier(metadata.AutoIncrement.Column)
+	}
+	maxRows := builder.dialect.MaxParameters() / len(columnNames)
+	if maxRows > maxRowsPerInsert {
+		maxRows = maxRowsPerInsert
+	}
+	batches := make([]InsertBatch, 0)
+	batch := InsertBatch{}
+	values := make([]string, 0)
+	size := len(prefix) + len(suffix)
+	for i := 0; i < slice.Len(); i++ {
+		_, args := builder.getColumnNamesAndValues(metadata, ObjectAt(slice, i), true)
+		row := "(" + builder.buildPlaceholders(len(batch.Args)+1, len(args)) + ")"
+		rowSize := len(row) + 1 + estimateArgsSize(args)
+		if batch.Count > 0 && (batch.Count == maxRows || size+rowSize > builder.maxQuerySize) {
+			batch.Query = prefix + " " + strings.Join(values, ",") + suffix
+			batches = append(batches, batch)
+			batch = InsertBatch{Offset: i}
+			values = values[:0]
+			size = len(prefix) + len(suffix)
+			// Placeholders are numbered from the start of each query.
+			row = "(" + builder.buildPlaceholders(1, len(args)) + ")"
+		}
+		values = append(values, row)
+		batch.Args = append(batch.Args, args...)
+		batch.Count++
+		size += rowSize
+	}
+	batch.Query = prefix + " " + strings.Join(values, ",") + suffix
+	return append(batches, batch), nil
+}
+
+// GetObjectSlice returns the slice of structs or struct pointers passed directly or by pointer.
+func GetObjectSlice(objects interface{}) (reflect.Value, error) {
+	slice := reflect.ValueOf(objects)
+	if slice.Kind() == reflect.Ptr {
+		slice = slice.Elem()
+	}
+	if slice.Kind() != reflect.Slice {
+		return reflect.Value{}, errors.Errorf("Unable to insert '%T' (expected a slice).", objects)
+	}
+	elemType := slice.Type().Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+		for i := 0; i < slice.Len(); i++ {
+			if slice.Index(i).IsNil() {
+				return reflect.Value{}, errors.Errorf("Unable to insert nil element at index %d.", i)
+			}
+		}
+	}
+	if elemType.Kind() != reflect.Struct {
+		return reflect.Value{}, errors.Errorf("Unable to insert '%T' (expected a slice of structs).", objects)
+	}
+	return slice, nil
+}
+
+// ObjectAt returns a pointer to the struct at the passed index of a slice of structs or struct pointers.
+func ObjectAt(slice reflect.Value, i int) interface{} {
+	elem := slice.Index(i)
+	if elem.Kind() == reflect.Ptr {
+		return elem.Interface()
+	}
+	return elem.Addr().Interface()
+}
+
+// estimateArgsSize approximates the number of bytes the passed arguments add to a query sent to the database.
+func estimateArgsSize(args []interface{}) int {
+	size := 0
+	for _, arg := range args {
+		switch arg := arg.(type) {
+		case string:
+			size += len(arg) + 8
+		case []byte:
+			size += len(arg) + 8
+		default:
+			size += 16
+		}
+	}
+	return size
+}
@@ -0,0 +1,81 @@
+package query_test
+
+import (
+	. "github.com/dtucker2/database/query"
+
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryBuilder_BuildInsertManyQueries(t *testing.T) {
+	t.Run("mysql", func(t *testing.T) {
+		objs := []objectWithTags{{Name: "First"}, {Name: "Second"}}
+		builder := NewQueryBuilder()
+		batches, err := builder.BuildInsertManyQueries(objs)
+		require.NoError(t, err)
+		require.Len(t, batches, 1)
+		assert.Equal(t, "INSERT INTO `objects` (`name`,`created_at`) VALUES (?,?),(?,?)", batches[0].Query)
+		assert.Equal(t, 0, batches[0].Offset)
+		assert.Equal(t, 2, batches[0].Count)
+		if assert.Len(t, batches[0].Args, 4) {
+			assert.Equal(t, "First", batches[0].Args[0])
+			assert.IsType(t, time.Time{}, batches[0].Args[1])
+			assert.Equal(t, "Second", batches[0].Args[2])
+		}
+	})
+	t.Run("postgres", func(t *testing.T) {
+		objs := []*objectWithTags{{Name: "First"}, {Name: "Second"}}
+		builder := NewQueryBuilder(WithDialect(PostgreSQL))
+		batches, err := builder.BuildInsertManyQueries(&objs)
+		require.NoError(t, err)
+		require.Len(t, batches, 1)
+		assert.Equal(t, `INSERT INTO "objects" ("name","created_at") VALUES ($1,$2),($3,$4) RETURNING "id"`, batches[0].Query)
+	})
+	t.Run("parameter limit", func(t *testing.T) {
+		objs := make([]objectWithTags, 1001)
+		builder := NewQueryBuilder(WithDialect(SQLServer))
+		batches, err := builder.BuildInsertManyQueries(objs)
+		require.NoError(t, err)
+		require.Len(t, batches, 2)
+		assert.Equal(t, 1000, batches[0].Count)
+		assert.Len(t, batches[0].Args, 2000)
+		assert.Equal(t, 1000, batches[1].Offset)
+		assert.Equal(t, 1, batches[1].Count)
+		assert.Equal(t, `INSERT INTO [objects] ([name],[created_at]) VALUES (@p1,@p2)`, batches[1].Query)
+	})
+	t.Run("query size", func(t *testing.T) {
+		objs := []objectWithTags{
+			{Name: strings.Repeat("a", 100)},
+			{Name: strings.Repeat("b", 100)},
+			{Name: strings.Repeat("c", 100)},
+		}
+		builder := NewQueryBuilder(WithMaxQuerySize(300))
+		batches, err := builder.BuildInsertManyQueries(objs)
+		require.NoError(t, err)
+		require.Len(t, batches, 3)
+		for i, batch := range batches {
+			assert.Equal(t, i, batch.Offset)
+			assert.Equal(t, 1, batch.Count)
+			assert.Equal(t, "INSERT INTO `objects` (`name`,`created_at`) VALUES (?,?)", batch.Query)
+		}
+	})
+	t.Run("empty", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		batches, err := builder.BuildInsertManyQueries([]objectWithTags{})
+		require.NoError(t, err)
+		assert.Empty(t, batches)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		_, err := builder.BuildInsertManyQueries(&objectWithTags{})
+		assert.Error(t, err)
+		_, err = builder.BuildInsertManyQueries([]int{1})
+		assert.Error(t, err)
+		_, err = builder.BuildInsertManyQueries([]*objectWithTags{nil})
+		assert.Error(t, err)
+	})
+}
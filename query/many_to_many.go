@@ -0,0 +1,113 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// JoinedOwnerKeyColumn is the name of the column selected by BuildManyToManyQuery holding the key of the parent each
+// related row is linked to.
+const JoinedOwnerKeyColumn = "joined_owner_key"
+
+// BuildManyToManyQuery constructs and returns a SELECT query and arguments for the related rows of the passed
+// ManyToMany relation linked to any of the passed parent keys. The related table is joined to the relation's join
+// table, whose foreign key column is selected as JoinedOwnerKeyColumn so that each row can be matched to its parent.
+func (builder *QueryBuilder) BuildManyToManyQuery(relation *Relation, ownerKeys []interface{}) (string, []interface{}, error) {
+	metadata, tableName, joinTable, err := builder.getManyToManyTables(relation)
+	if err != nil {
+		return "", nil, err
+	}
+	columnNames := make([]string, 0, len(metadata.Fields)+1)
+	for _, field := range metadata.Fields {
+		columnNames = append(columnNames, tableName+"."+builder.dialect.QuoteIdentifier(field.Column))
+	}
+	foreignKey := joinTable + "." + builder.dialect.QuoteIdentifier(relation.ForeignKey)
+	columnNames = append(columnNames, foreignKey+" AS "+builder.dialect.QuoteIdentifier(JoinedOwnerKeyColumn))
+	where := "1=0"
+	if len(ownerKeys) > 0 {
+		where = foreignKey + " IN (" + builder.buildPlaceholders(1, len(ownerKeys)) + ")"
+	}
+	if builder.softDeletes(metadata) {
+		where += " AND " + tableName + "." + builder.buildNotDeletedCondition(metadata)
+	}
+	return strings.Join([]string{
+		"SELECT",
+		strings.Join(columnNames, ","),
+		"FROM",
+		tableName,
+		"INNER JOIN",
+		joinTable,
+		"ON",
+		joinTable + "." + builder.dialect.QuoteIdentifier(relation.References) + "=" + tableName + "." + builder.dialect.QuoteIdentifier(metadata.Keys[0].Column),
+		"WHERE",
+		where,
+	}, " "), ownerKeys, nil
+}
+
+// BuildJoinInsertQuery constructs and returns an INSERT query and arguments linking the passed parent key to each of
+// the passed related keys in the join table of the passed ManyToMany relation. Each related key takes two parameters,
+// callers must split the keys to keep within the dialect's parameter limit.
+func (builder *QueryBuilder) BuildJoinInsertQuery(relation *Relation, ownerKey interface{}, relatedKeys []interface{}) (string, []interface{}, error) {
+	_, _, joinTable, err := builder.getManyToManyTables(relation)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(relatedKeys) == 0 {
+		return "", nil, errors.New("Unable to build join insert query (no related rows passed).")
+	}
+	values := make([]string, len(relatedKeys))
+	args := make([]interface{}, 0, len(relatedKeys)*2)
+	for i, relatedKey := range relatedKeys {
+		values[i] = "(" + builder.buildPlaceholders(len(args)+1, 2) + ")"
+		args = append(args, ownerKey, relatedKey)
+	}
+	return strings.Join([]string{
+		"INSERT INTO",
+		joinTable,
+		"(" + builder.dialect.QuoteIdentifier(relation.ForeignKey) + "," + builder.dialect.QuoteIdentifier(relation.References) + ")",
+		"VALUES",
+		strings.Join(values, ","),
+	}, " "), args, nil
+}
+
+// BuildJoinDeleteQuery constructs and returns a DELETE query and arguments unlinking the passed parent key from each
+// of the passed related keys in the join table of the passed ManyToMany relation. Every related row is unlinked when
+// no related keys are passed.
+func (builder *QueryBuilder) BuildJoinDeleteQuery(relation *Relation, ownerKey interface{}, relatedKeys []interface{}) (string, []interface{}, error) {
+	_, _, joinTable, err := builder.getManyToManyTables(relation)
+	if err != nil {
+		return "", nil, err
+	}
+	where := builder.dialect.QuoteIdentifier(relation.ForeignKey) + "=" + builder.dialect.Placeholder(1)
+	if len(relatedKeys) > 0 {
+		where += " AND " + builder.dialect.QuoteIdentifier(relation.References) + " IN (" + builder.buildPlaceholders(2, len(relatedKeys)) + ")"
+	}
+	return strings.Join([]string{
+		"DELETE",
+		"FROM",
+		joinTable,
+		"WHERE",
+		where,
+	}, " "), append([]interface{}{ownerKey}, relatedKeys...), nil
+}
+
+// getManyToManyTables returns the related metadata and the quoted related and join table names of the passed relation.
+func (builder *QueryBuilder) getManyToManyTables(relation *Relation) (*Metadata, string, string, error) {
+	if relation.Kind != ManyToMany {
+		return nil, "", "", errors.Errorf("Unable to join relation '%s' (expected a %s relation).", relation.Name, ManyToMany)
+	}
+	metadata, tableName, err := builder.getMetadataAndTableName(reflect.New(relation.Type).Interface())
+	if err != nil {
+		return nil, "", "", err
+	}
+	if len(metadata.Keys) != 1 {
+		return nil, "", "", errors.Errorf("Unable to join relation '%s' ('%s' must have a single primary key field).", relation.Name, metadata.Type)
+	}
+	joinTable, err := builder.quoteTableName(relation.JoinTable)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return metadata, tableName, joinTable, nil
+}
@@ -0,0 +1,96 @@
+package query_test
+
+import (
+	. "github.com/dtucker2/database/query"
+
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type user struct {
+	Id    int     `name:"id" key:"true"`
+	Roles []*role `rel:"many-to-many" join:"user_roles" fk:"user_id" ref:"role_id"`
+}
+
+type editor struct {
+	Id    int     `name:"id" key:"true"`
+	Roles []*role `rel:"many-to-many" join:"user_roles"`
+}
+
+func (obj *editor) GetTableName() string {
+	return "users"
+}
+
+type role struct {
+	Id   int    `name:"id" key:"true"`
+	Name string `name:"name"`
+}
+
+func getRolesRelation(t *testing.T) *Relation {
+	metadata, err := GetMetadata(reflect.TypeOf(user{}))
+	require.NoError(t, err)
+	relation, ok := metadata.Relation("Roles")
+	require.True(t, ok)
+	return relation
+}
+
+func TestQueryBuilder_ManyToMany(t *testing.T) {
+	relation := getRolesRelation(t)
+	assert.Equal(t, ManyToMany, relation.Kind)
+	assert.Equal(t, "user_roles", relation.JoinTable)
+	assert.Equal(t, "user_id", relation.ForeignKey)
+	assert.Equal(t, "role_id", relation.References)
+	t.Run("select", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		query, args, err := builder.BuildManyToManyQuery(relation, []interface{}{1, 2})
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT `roles`.`id`,`roles`.`name`,`user_roles`.`user_id` AS `joined_owner_key` FROM `roles` "+
+			"INNER JOIN `user_roles` ON `user_roles`.`role_id`=`roles`.`id` WHERE `user_roles`.`user_id` IN (?,?)", query)
+		assert.Equal(t, []interface{}{1, 2}, args)
+	})
+	t.Run("insert", func(t *testing.T) {
+		builder := NewQueryBuilder(WithDialect(PostgreSQL))
+		query, args, err := builder.BuildJoinInsertQuery(relation, 1, []interface{}{3, 4})
+		require.NoError(t, err)
+		assert.Equal(t, `INSERT INTO "user_roles" ("user_id","role_id") VALUES ($1,$2),($3,$4)`, query)
+		assert.Equal(t, []interface{}{1, 3, 1, 4}, args)
+		_, _, err = builder.BuildJoinInsertQuery(relation, 1, nil)
+		assert.Error(t, err)
+	})
+	t.Run("delete", func(t *testing.T) {
+		builder := NewQueryBuilder(WithDialect(PostgreSQL))
+		query, args, err := builder.BuildJoinDeleteQuery(relation, 1, []interface{}{3, 4})
+		require.NoError(t, err)
+		assert.Equal(t, `DELETE FROM "user_roles" WHERE "user_id"=$1 AND "role_id" IN ($2,$3)`, query)
+		assert.Equal(t, []interface{}{1, 3, 4}, args)
+		query, args, err = builder.BuildJoinDeleteQuery(relation, 1, nil)
+		require.NoError(t, err)
+		assert.Equal(t, `DELETE FROM "user_roles" WHERE "user_id"=$1`, query)
+		assert.Equal(t, []interface{}{1}, args)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := GetMetadata(reflect.TypeOf(struct {
+			Roles []role `rel:"many-to-many" fk:"user_id" ref:"role_id"`
+		}{}))
+		assert.Error(t, err)
+		_, err = GetMetadata(reflect.TypeOf(struct {
+			Roles role `rel:"many-to-many" join:"user_roles" fk:"user_id" ref:"role_id"`
+		}{}))
+		assert.Error(t, err)
+	})
+	t.Run("default columns", func(t *testing.T) {
+		metadata, err := GetMetadata(reflect.TypeOf(editor{}))
+		require.NoError(t, err)
+		relation, ok := metadata.Relation("Roles")
+		require.True(t, ok)
+		assert.Equal(t, "user_id", relation.ForeignKey)
+		assert.Equal(t, "role_id", relation.References)
+		builder := NewQueryBuilder(WithDialect(PostgreSQL))
+		query, _, err := builder.BuildJoinInsertQuery(relation, 1, []interface{}{3})
+		require.NoError(t, err)
+		assert.Equal(t, `INSERT INTO "user_roles" ("user_id","role_id") VALUES ($1,$2)`, query)
+	})
+}
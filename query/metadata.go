@@ -0,0 +1,303 @@
+package query
+
+import (
+	"database/sql"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jinzhu/inflection"
+	"github.com/pkg/errors"
+)
+
+// metadataCache holds the Metadata of every struct type seen so far, keyed by reflect.Type.
+var metadataCache sync.Map
+
+// Metadata describes how a struct type maps to a database table. It is built once per type and cached.
+type Metadata struct {
+	// Type is the struct type described.
+	Type reflect.Type
+	// TableName is the table name derived from the struct name. It is overridden by implementing GetTableName.
+	TableName string
+	// Fields holds every field mapped to a column, in declaration order.
+	Fields []*Field
+	// Keys holds the fields forming the primary key.
+	Keys []*Field
+	// AutoIncrement holds the auto-increment field, if any.
+	AutoIncrement *Field
+	// DeletedAt holds the field tagged 'type:"deleted_at"', if any, which marks rows as soft deleted.
+	DeletedAt *Field
+	// Version holds the field tagged 'type:"version"', if any, used for optimistic locking.
+	Version *Field
+	// Relations holds every field tagged with 'rel', in declaration order.
+	Relations []*Relation
+
+	columns   map[string]*Field
+	relations map[string]*Relation
+}
+
+// Field describes how a single struct field maps to a column.
+type Field struct {
+	// Name is the name of the struct field.
+	Name string
+	// Column is the name of the column, taken from the 'name' tag or defaulting to the field name.
+	Column string
+	// Index is the index sequence of the field for use with reflect.Value.FieldByIndex.
+	Index []int
+	// Type is the type of the struct field.
+	Type reflect.Type
+	// Key reports whether the field is part of the primary key.
+	Key bool
+	// AutoIncrement reports whether the field is tagged 'type:"auto-increment"'.
+	AutoIncrement bool
+	// CreatedAt reports whether the field is tagged 'type:"created_at"'.
+	CreatedAt bool
+	// UpdatedAt reports whether the field is tagged 'type:"updated_at"'.
+	UpdatedAt bool
+	// DeletedAt reports whether the field is tagged 'type:"deleted_at"'.
+	DeletedAt bool
+	// Version reports whether the field is tagged 'type:"version"'.
+	Version bool
+	// ReadOnly reports whether the field is tagged 'readonly:"true"', meaning it is selected but never written.
+	ReadOnly bool
+	// Size is the length of string and []byte columns, taken from the 'size' tag. Zero means the dialect's default.
+	Size int
+	// Nullable reports whether the column accepts NULL, taken from the 'nullable' tag or defaulting to true for
+	// pointers and the sql.Null types.
+	Nullable bool
+	// Default is the SQL expression used as the column's default value, taken verbatim from the 'default' tag.
+	Default string
+	// Unique reports whether the field is tagged 'unique:"true"'.
+	Unique bool
+	// IndexName is the name of the index covering the column, taken from the 'index' tag. A value of "true" creates an
+	// index named after the table and column, fields sharing any other name form a single multi-column index.
+	IndexName string
+}
+
+// GetMetadata returns the Metadata of the passed struct or struct pointer type, building and caching it on first use.
+func GetMetadata(typ reflect.Type) (*Metadata, error) {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if cached, ok := metadataCache.Load(typ); ok {
+		return cached.(*Metadata), nil
+	}
+	metadata, err := newMetadata(typ)
+	if err != nil {
+		return nil, err
+	}
+	cached, _ := metadataCache.LoadOrStore(typ, metadata)
+	return cached.(*Metadata), nil
+}
+
+// Column returns the field mapped to the passed column name.
+func (metadata *Metadata) Column(name string) (*Field, bool) {
+	field, ok := metadata.columns[name]
+	return field, ok
+}
+
+// Value returns the field's value within the passed struct value.
+func (field *Field) Value(structValue reflect.Value) reflect.Value {
+	return structValue.FieldByIndex(field.Index)
+}
+
+// ValueType returns the type of the values stored by the field, removing pointers and the sql.Null types.
+func (field *Field) ValueType() reflect.Type {
+	typ := field.Type
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if valueType, ok := nullTypes[typ]; ok {
+		return valueType
+	}
+	return typ
+}
+
+// insertable reports whether the field is written by insert (insertion) or update queries. The deleted_at column is
+// only written by delete and restore queries.
+func (field *Field) insertable(insertion bool) bool {
+	switch {
+	case field.AutoIncrement, field.ReadOnly, field.DeletedAt:
+		return false
+	case field.CreatedAt:
+		return insertion
+	case field.UpdatedAt:
+		return !insertion
+	case field.Version:
+		// Update queries increment the version column rather than writing the field.
+		return insertion
+	}
+	return true
+}
+
+func newMetadata(typ reflect.Type) (*Metadata, error) {
+	if typ.Kind() != reflect.Struct {
+		return nil, errors.Errorf("Unable to map type '%s' (expected a struct or pointer to a struct).", typ)
+	}
+	metadata := &Metadata{
+		Type:      typ,
+		TableName: inflection.Plural(typ.Name()),
+		Fields:    make([]*Field, 0, typ.NumField()),
+		columns:   make(map[string]*Field, typ.NumField()),
+		relations: make(map[string]*Relation),
+	}
+	if err := metadata.addFields(typ, nil, "", ""); err != nil {
+		return nil, err
+	}
+	if len(metadata.Keys) == 0 {
+		// Attempt to default to any field with a name of 'Id' or a name tag of 'id'.
+		for _, field := range metadata.Fields {
+			if field.Column == "Id" || field.Column == "id" {
+				field.Key = true
+				metadata.Keys = []*Field{field}
+				break
+			}
+		}
+	}
+	return metadata, nil
+}
+
+// addFields adds the fields of the passed struct type, found at the passed index sequence within the metadata's type.
+// Anonymous embedded structs and struct fields tagged with a column prefix are flattened into their parent's columns.
+func (metadata *Metadata) addFields(typ reflect.Type, index []int, columnPrefix, namePrefix string) error {
+	for i := 0; i < typ.NumField(); i++ {
+		structField := typ.Field(i)
+		if structField.Tag.Get(tagName) == tagNameIgnore {
+			continue
+		}
+		fieldIndex := append(append([]int(nil), index...), i)
+		prefix, hasPrefix := structField.Tag.Lookup(tagPrefix)
+		_, hasRelation := structField.Tag.Lookup(tagRelation)
+		if (structField.Anonymous || hasPrefix) && !hasRelation && structField.Type.Kind() == reflect.Struct {
+			name := namePrefix + structField.Name + "."
+			if structField.Anonymous {
+				name = namePrefix
+			}
+			if err := metadata.addFields(structField.Type, fieldIndex, columnPrefix+prefix, name); err != nil {
+				return err
+			}
+			continue
+		}
+		if (structField.Anonymous || hasPrefix) && !hasRelation && structField.PkgPath == "" && isStructPointer(structField.Type) {
+			// Their fields cannot be read or written while the pointer is nil.
+			return errors.Errorf("Unable to map field '%s' (embedded pointers to structs are not supported, embed the struct by value instead).", namePrefix+structField.Name)
+		}
+		// Unexported fields cannot be set when scanning.
+		if structField.PkgPath != "" {
+			continue
+		}
+		if hasRelation {
+			// The related struct's metadata is not built here, as relations are often circular.
+			relation, err := newRelation(metadata.Type, structField, fieldIndex, namePrefix+structField.Name)
+			if err != nil {
+				return err
+			}
+			metadata.Relations = append(metadata.Relations, relation)
+			metadata.relations[relation.Name] = relation
+			continue
+		}
+		field := &Field{
+			Name:      namePrefix + structField.Name,
+			Column:    structField.Tag.Get(tagName),
+			Index:     fieldIndex,
+			Type:      structField.Type,
+			Key:       structField.Tag.Get(tagKey) == "true",
+			ReadOnly:  structField.Tag.Get(tagReadOnly) == "true",
+			Nullable:  isNullableType(structField.Type),
+			Default:   structField.Tag.Get(tagDefault),
+			Unique:    structField.Tag.Get(tagUnique) == "true",
+			IndexName: structField.Tag.Get(tagIndex),
+		}
+		if field.Column == "" {
+			field.Column = structField.Name
+		}
+		field.Column = columnPrefix + field.Column
+		if err := validateIdentifier(field.Column); err != nil {
+			return errors.Wrapf(err, "Invalid column name for field '%s'.", field.Name)
+		}
+		if size, ok := structField.Tag.Lookup(tagSize); ok {
+			var err error
+			if field.Size, err = strconv.Atoi(size); err != nil || field.Size < 0 {
+				return errors.Errorf("Invalid size '%s' for field '%s' (expected a positive integer).", size, field.Name)
+			}
+		}
+		if nullable, ok := structField.Tag.Lookup(tagNullable); ok {
+			field.Nullable = nullable == "true"
+		}
+		if existing, ok := metadata.columns[field.Column]; ok {
+			return errors.Errorf("Fields '%s' and '%s' are both mapped to column '%s'.", existing.Name, field.Name, field.Column)
+		}
+		switch structField.Tag.Get(tagType) {
+		case tagTypeAutoIncrement:
+			field.AutoIncrement = true
+			if metadata.AutoIncrement == nil {
+				metadata.AutoIncrement = field
+			}
+		case tagTypeCreatedAt:
+			field.CreatedAt = true
+		case tagTypeUpdatedAt:
+			field.UpdatedAt = true
+		case tagTypeDeletedAt:
+			field.DeletedAt = true
+			if metadata.DeletedAt == nil {
+				metadata.DeletedAt = field
+			}
+		case tagTypeVersion:
+			field.Version = true
+			if metadata.Version == nil {
+				metadata.Version = field
+			}
+		}
+		if field.Key {
+			metadata.Keys = append(metadata.Keys, field)
+		}
+		metadata.Fields = append(metadata.Fields, field)
+		metadata.columns[field.Column] = field
+	}
+	return nil
+}
+
+// nullTypes maps the sql.Null types to the type of the value they hold.
+var nullTypes = map[reflect.Type]reflect.Type{
+	reflect.TypeOf(sql.NullBool{}):    reflect.TypeOf(false),
+	reflect.TypeOf(sql.NullFloat64{}): reflect.TypeOf(float64(0)),
+	reflect.TypeOf(sql.NullInt32{}):   reflect.TypeOf(int32(0)),
+	reflect.TypeOf(sql.NullInt64{}):   reflect.TypeOf(int64(0)),
+	reflect.TypeOf(sql.NullString{}):  reflect.TypeOf(""),
+	reflect.TypeOf(sql.NullTime{}):    reflect.TypeOf(time.Time{}),
+}
+
+// isNullableType reports whether values of the passed type can hold NULL.
+func isNullableType(typ reflect.Type) bool {
+	_, ok := nullTypes[typ]
+	return ok || typ.Kind() == reflect.Ptr
+}
+
+// scannerType is the type of the sql.Scanner interface.
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// isStructPointer reports whether the passed type is a pointer to a struct whose fields would be flattened into columns,
+// rather than a single value such as a *time.Time or a sql.Scanner.
+func isStructPointer(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Ptr && typ.Elem().Kind() == reflect.Struct && typ.Elem() != timeType && !typ.Implements(scannerType)
+}
+
+// validateIdentifier returns an error for table and column names which cannot be safely quoted: empty names, invalid
+// UTF-8 and control characters such as NUL.
+func validateIdentifier(name string) error {
+	if name == "" {
+		return errors.New("Identifier must not be empty.")
+	}
+	if !utf8.ValidString(name) {
+		return errors.Errorf("Identifier '%s' is not valid UTF-8.", name)
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			return errors.Errorf("Identifier %q contains a control character.", name)
+		}
+	}
+	return nil
+}
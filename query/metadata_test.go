@@ -0,0 +1,142 @@
+package query_test
+
+import (
+	. "github.com/dtucker2/database/query"
+
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMetadata(t *testing.T) {
+	t.Run("basic", func(t *testing.T) {
+		metadata, err := GetMetadata(reflect.TypeOf(&object{}))
+		require.NoError(t, err)
+		assert.Equal(t, "objects", metadata.TableName)
+		if assert.Len(t, metadata.Fields, 4) {
+			assert.Equal(t, "Id", metadata.Fields[0].Column)
+			assert.Equal(t, "Name", metadata.Fields[1].Column)
+		}
+		if assert.Len(t, metadata.Keys, 1) {
+			assert.Equal(t, "Id", metadata.Keys[0].Column)
+		}
+		assert.Nil(t, metadata.AutoIncrement)
+	})
+	t.Run("tags", func(t *testing.T) {
+		metadata, err := GetMetadata(reflect.TypeOf(objectWithTags{}))
+		require.NoError(t, err)
+		if assert.Len(t, metadata.Fields, 4) {
+			assert.True(t, metadata.Fields[0].AutoIncrement)
+			assert.True(t, metadata.Fields[2].CreatedAt)
+			assert.True(t, metadata.Fields[3].UpdatedAt)
+		}
+		if assert.NotNil(t, metadata.AutoIncrement) {
+			assert.Equal(t, "id", metadata.AutoIncrement.Column)
+		}
+		field, ok := metadata.Column("created_at")
+		if assert.True(t, ok) {
+			assert.Equal(t, "CreatedAt", field.Name)
+		}
+		_, ok = metadata.Column("CreatedAt")
+		assert.False(t, ok)
+	})
+	t.Run("composite key", func(t *testing.T) {
+		metadata, err := GetMetadata(reflect.TypeOf(&objectWithCompositeKey{}))
+		require.NoError(t, err)
+		if assert.Len(t, metadata.Keys, 2) {
+			assert.Equal(t, "user_id", metadata.Keys[0].Column)
+			assert.Equal(t, "role_id", metadata.Keys[1].Column)
+		}
+	})
+	t.Run("no primary key", func(t *testing.T) {
+		metadata, err := GetMetadata(reflect.TypeOf(&objectWithNoKey{}))
+		require.NoError(t, err)
+		assert.Empty(t, metadata.Keys)
+	})
+	t.Run("cached", func(t *testing.T) {
+		first, err := GetMetadata(reflect.TypeOf(&object{}))
+		require.NoError(t, err)
+		second, err := GetMetadata(reflect.TypeOf(object{}))
+		require.NoError(t, err)
+		assert.True(t, first == second)
+	})
+	t.Run("concurrent", func(t *testing.T) {
+		var wg sync.WaitGroup
+		results := make([]*Metadata, 10)
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i], _ = GetMetadata(reflect.TypeOf(&objectWithReservedNames{}))
+			}(i)
+		}
+		wg.Wait()
+		for _, result := range results {
+			assert.True(t, result == results[0])
+		}
+	})
+	t.Run("not a struct", func(t *testing.T) {
+		_, err := GetMetadata(reflect.TypeOf(new(int)))
+		require.Error(t, err)
+	})
+	t.Run("invalid column name", func(t *testing.T) {
+		_, err := GetMetadata(reflect.TypeOf(&objectWithInvalidName{}))
+		require.Error(t, err)
+	})
+}
+
+type objectWithDuplicateColumns struct {
+	Id        int        `name:"id"`
+	CreatedAt *time.Time `name:"created_at"`
+	Timestamps
+}
+
+type objectWithEmbeddedPointer struct {
+	Id int `name:"id"`
+	*Timestamps
+}
+
+type objectWithPrefixedPointer struct {
+	Id             int      `name:"id"`
+	BillingAddress *Address `prefix:"billing_"`
+}
+
+type objectWithEmbeddedTimePointer struct {
+	Id int `name:"id"`
+	*time.Time
+}
+
+func TestGetMetadata_Embedded(t *testing.T) {
+	t.Run("flattened", func(t *testing.T) {
+		metadata, err := GetMetadata(reflect.TypeOf(&objectWithEmbeddedStructs{}))
+		require.NoError(t, err)
+		field, ok := metadata.Column("billing_city")
+		if assert.True(t, ok) {
+			assert.Equal(t, "BillingAddress.City", field.Name)
+			assert.Equal(t, []int{2, 1}, field.Index)
+		}
+		field, ok = metadata.Column("created_at")
+		if assert.True(t, ok) {
+			assert.Equal(t, "CreatedAt", field.Name)
+			assert.True(t, field.CreatedAt)
+		}
+	})
+	t.Run("duplicate columns", func(t *testing.T) {
+		_, err := GetMetadata(reflect.TypeOf(&objectWithDuplicateColumns{}))
+		require.Error(t, err)
+	})
+	t.Run("pointers", func(t *testing.T) {
+		_, err := GetMetadata(reflect.TypeOf(&objectWithEmbeddedPointer{}))
+		assert.Error(t, err)
+		_, err = GetMetadata(reflect.TypeOf(&objectWithPrefixedPointer{}))
+		assert.Error(t, err)
+		metadata, err := GetMetadata(reflect.TypeOf(&objectWithEmbeddedTimePointer{}))
+		require.NoError(t, err)
+		_, ok := metadata.Column("Time")
+		assert.True(t, ok)
+	})
+}
@@ -2,10 +2,10 @@ package query
 
 import (
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
-	"github.com/jinzhu/inflection"
 	"github.com/pkg/errors"
 )
 
@@ -13,171 +13,539 @@ const (
 	tagName              = "name"
 	tagType              = "type"
 	tagKey               = "key"
+	tagReadOnly          = "readonly"
+	tagPrefix            = "prefix"
+	tagRelation          = "rel"
+	tagForeignKey        = "fk"
+	tagJoinTable         = "join"
+	tagReferences        = "ref"
+	tagSize              = "size"
+	tagNullable          = "nullable"
+	tagDefault           = "default"
+	tagUnique            = "unique"
+	tagIndex             = "index"
+	tagNameIgnore        = "-"
 	tagTypeAutoIncrement = "auto-increment"
 	tagTypeCreatedAt     = "created_at"
 	tagTypeUpdatedAt     = "updated_at"
+	tagTypeDeletedAt     = "deleted_at"
+	tagTypeVersion       = "version"
 )
 
-// QueryBuilder provides methods to generate MySQL queries from pointers to structs.
-type QueryBuilder struct{}
+// ErrNoPrimaryKey is returned when building a query which requires a primary key from a struct without one.
+var ErrNoPrimaryKey = errors.Errorf("Unable to identify primary key (struct is missing a '%s:\"true\"' tag).", tagKey)
+
+// QueryBuilder provides methods to generate SQL queries from pointers to structs.
+type QueryBuilder struct {
+	dialect      Dialect
+	maxQuerySize int
+	unscoped     bool
+}
+
+// Option configures a QueryBuilder.
+type Option func(*QueryBuilder)
+
+// WithDialect sets the Dialect used to generate queries. MySQL is used by default.
+func WithDialect(dialect Dialect) Option {
+	return func(builder *QueryBuilder) {
+		builder.dialect = dialect
+	}
+}
+
+// WithMaxQuerySize sets the approximate maximum size in bytes of a query and its arguments, used to split the queries
+// built by BuildInsertManyQueries. It defaults to 4MiB, the smallest default of MySQL's max_allowed_packet.
+func WithMaxQuerySize(bytes int) Option {
+	return func(builder *QueryBuilder) {
+		builder.maxQuerySize = bytes
+	}
+}
 
 // NewQueryBuilder returns a pointer to a new instance of the QueryBuilder struct.
-func NewQueryBuilder() *QueryBuilder {
-	return &QueryBuilder{}
+func NewQueryBuilder(options ...Option) *QueryBuilder {
+	builder := &QueryBuilder{
+		dialect:      MySQL,
+		maxQuerySize: defaultMaxQuerySize,
+	}
+	for _, option := range options {
+		option(builder)
+	}
+	return builder
 }
 
-// BuildInsertQuery constructs and returns a MySQL INSERT query and arguments from the passed object (struct pointer).
-func (builder *QueryBuilder) BuildInsertQuery(object interface{}) (string, []interface{}) {
-	columnNames, args := builder.getColumnNamesAndValues(object, true)
-	values := strings.Repeat("?,", len(args))
-	if len(values) > 0 {
-		values = values[:len(values)-1]
+// Dialect returns the Dialect used to generate queries.
+func (builder *QueryBuilder) Dialect() Dialect {
+	return builder.dialect
+}
+
+// BuildInsertQuery constructs and returns an INSERT query and arguments from the passed object (struct pointer).
+// When the dialect supports it, a RETURNING clause is appended for the auto-increment column.
+func (builder *QueryBuilder) BuildInsertQuery(object interface{}) (string, []interface{}, error) {
+	metadata, tableName, err := builder.getMetadataAndTableName(object)
+	if err != nil {
+		return "", nil, err
 	}
-	return strings.Join([]string{
+	columnNames, args := builder.getColumnNamesAndValues(metadata, object, true)
+	parts := []string{
 		"INSERT INTO",
-		builder.getTableName(object),
+		tableName,
 		"(" + strings.Join(columnNames, ",") + ")",
 		"VALUES",
-		"(" + values + ")",
-	}, " "), args
+		"(" + builder.buildPlaceholders(1, len(args)) + ")",
+	}
+	if metadata.AutoIncrement != nil && builder.dialect.SupportsReturning() {
+		parts = append(parts, "RETURNING", builder.dialect.QuoteIdentifier(metadata.AutoIncrement.Column))
+	}
+	return strings.Join(parts, " "), args, nil
 }
 
-// BuildUpdateQuery constructs and returns a MySQL UPDATE query and arguments from the passed object (struct pointer).
+// BuildUpdateQuery constructs and returns an UPDATE query and arguments from the passed object (struct pointer).
+// Objects with a field tagged 'type:"version"' are optimistically locked: the row is only updated while its version
+// column matches the field, and the column is incremented.
 func (builder *QueryBuilder) BuildUpdateQuery(object interface{}) (string, []interface{}, error) {
-	keyName, keyValue, err := builder.getPrimaryKeyNameAndValue(object)
+	metadata, tableName, err := builder.getMetadataAndTableName(object)
+	if err != nil {
+		return "", nil, err
+	}
+	keyValues, err := builder.getPrimaryKeyValues(metadata, object)
+	if err != nil {
+		return "", nil, err
+	}
+	fields, err := builder.getUpdateFields(metadata, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	return builder.buildUpdateQuery(metadata, tableName, object, keyValues, fields, nil)
+}
+
+// BuildUpdateColumnsQuery constructs and returns an UPDATE query and arguments from the passed object (struct pointer)
+// which only writes the passed columns, along with any updated_at and version columns.
+func (builder *QueryBuilder) BuildUpdateColumnsQuery(object interface{}, columns ...string) (string, []interface{}, error) {
+	metadata, tableName, err := builder.getMetadataAndTableName(object)
+	if err != nil {
+		return "", nil, err
+	}
+	keyValues, err := builder.getPrimaryKeyValues(metadata, object)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(columns) == 0 {
+		return "", nil, errors.New("Unable to build update query (no columns passed).")
+	}
+	fields, err := builder.getUpdateFields(metadata, columns)
 	if err != nil {
 		return "", nil, err
 	}
-	columnNames, args := builder.getColumnNamesAndValues(object, false)
+	return builder.buildUpdateQuery(metadata, tableName, object, keyValues, fields, nil)
+}
+
+// BuildUpdateMapQuery constructs and returns an UPDATE query and arguments for the row of the passed object (struct
+// pointer) which writes the passed values, keyed by column name, along with any updated_at and version columns. The
+// object's other fields, other than its primary key and version, are ignored.
+func (builder *QueryBuilder) BuildUpdateMapQuery(object interface{}, values map[string]interface{}) (string, []interface{}, error) {
+	metadata, tableName, err := builder.getMetadataAndTableName(object)
+	if err != nil {
+		return "", nil, err
+	}
+	keyValues, err := builder.getPrimaryKeyValues(metadata, object)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(values) == 0 {
+		return "", nil, errors.New("Unable to build update query (no columns passed).")
+	}
+	// Map iteration order is random, the columns are sorted so that the same query is built every time.
+	columns := make([]string, 0, len(values))
+	for column := range values {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	fields, err := builder.getUpdateFields(metadata, columns)
+	if err != nil {
+		return "", nil, err
+	}
+	return builder.buildUpdateQuery(metadata, tableName, object, keyValues, fields, values)
+}
+
+// buildUpdateQuery builds an UPDATE query writing the passed fields. Values are taken from the passed map, keyed by
+// column name, when present and from the object otherwise.
+func (builder *QueryBuilder) buildUpdateQuery(metadata *Metadata, tableName string, object interface{}, keyValues []interface{}, fields []*Field, values map[string]interface{}) (string, []interface{}, error) {
+	val := reflect.ValueOf(object).Elem()
+	now := time.Now()
+	assignments := make([]string, 0, len(fields))
+	args := make([]interface{}, 0, len(fields)+len(keyValues)+1)
+	for _, field := range fields {
+		column := builder.dialect.QuoteIdentifier(field.Column)
+		value, ok := values[field.Column]
+		switch {
+		case field.Version:
+			assignments = append(assignments, column+"="+column+"+1")
+			continue
+		case field.UpdatedAt:
+			args = append(args, now)
+		case ok:
+			args = append(args, value)
+		default:
+			args = append(args, field.Value(val).Interface())
+		}
+		assignments = append(assignments, column+"="+builder.dialect.Placeholder(len(args)))
+	}
+	// Incrementing the version alone is a valid update, e.g. to invalidate copies of the row read by others.
+	if len(assignments) == 0 {
+		return "", nil, errors.New("Unable to build update query (struct has no columns to update).")
+	}
+	where := builder.buildKeyConditions(metadata, len(args)+1)
+	args = append(args, keyValues...)
+	if metadata.Version != nil {
+		args = append(args, metadata.Version.Value(val).Interface())
+		where += " AND " + builder.dialect.QuoteIdentifier(metadata.Version.Column) + "=" + builder.dialect.Placeholder(len(args))
+	}
 	return strings.Join([]string{
 		"UPDATE",
-		builder.getTableName(object),
+		tableName,
 		"SET",
-		builder.buildUpdateValues(columnNames),
+		strings.Join(assignments, ","),
 		"WHERE",
-		keyName + "=?",
-	}, " "), append(args, keyValue), nil
+		where,
+	}, " "), args, nil
 }
 
-// BuildDeleteQuery constructs and returns a MySQL DELETE query and arguments from the passed object (struct pointer).
+// BuildDeleteQuery constructs and returns a DELETE query and arguments from the passed object (struct pointer).
+// Objects with a field tagged 'type:"deleted_at"' are soft deleted instead: an UPDATE query sets the field's column to
+// the current time, passed as the first argument. See BuildForceDeleteQuery and Unscoped.
 func (builder *QueryBuilder) BuildDeleteQuery(object interface{}) (string, []interface{}, error) {
-	keyName, keyValue, err := builder.getPrimaryKeyNameAndValue(object)
+	metadata, tableName, err := builder.getMetadataAndTableName(object)
+	if err != nil {
+		return "", nil, err
+	}
+	if builder.softDeletes(metadata) {
+		return builder.buildSoftDeleteQuery(metadata, tableName, object)
+	}
+	return builder.buildHardDeleteQuery(metadata, tableName, object)
+}
+
+func (builder *QueryBuilder) buildHardDeleteQuery(metadata *Metadata, tableName string, object interface{}) (string, []interface{}, error) {
+	keyValues, err := builder.getPrimaryKeyValues(metadata, object)
 	if err != nil {
 		return "", nil, err
 	}
 	return strings.Join([]string{
 		"DELETE",
 		"FROM",
-		builder.getTableName(object),
+		tableName,
 		"WHERE",
-		keyName + "=?",
-	}, " "), []interface{}{keyValue}, nil
+		builder.buildKeyConditions(metadata, 1),
+	}, " "), keyValues, nil
 }
 
-// BuildSelectQuery constructs and returns a MySQL SELECT query and arguments from the passed object (struct pointer).
+// BuildSelectQuery constructs and returns a SELECT query and arguments from the passed object (struct pointer).
+// Soft deleted rows are excluded unless the builder is unscoped.
 func (builder *QueryBuilder) BuildSelectQuery(object interface{}) (string, []interface{}, error) {
-	keyName, keyValue, err := builder.getPrimaryKeyNameAndValue(object)
+	metadata, tableName, err := builder.getMetadataAndTableName(object)
 	if err != nil {
 		return "", nil, err
 	}
+	keyValues, err := builder.getPrimaryKeyValues(metadata, object)
+	if err != nil {
+		return "", nil, err
+	}
+	where := builder.buildKeyConditions(metadata, 1)
+	if builder.softDeletes(metadata) {
+		where += " AND " + builder.buildNotDeletedCondition(metadata)
+	}
 	return strings.Join([]string{
 		"SELECT",
-		strings.Join(builder.getColumnNames(object), ","),
+		strings.Join(builder.getColumnNames(metadata), ","),
 		"FROM",
-		builder.getTableName(object),
+		tableName,
 		"WHERE",
-		keyName + "=?",
-	}, " "), []interface{}{keyValue}, nil
+		where,
+	}, " "), keyValues, nil
 }
 
-func (builder *QueryBuilder) getTableName(object interface{}) string {
-	if namer, ok := object.(tableNamer); ok {
-		return namer.GetTableName()
+// BuildExistsQuery constructs and returns a SELECT query and arguments returning a row when a row with the passed
+// object's (struct pointer) primary key exists, soft deleted or not, like the rows matched by BuildUpdateQuery.
+func (builder *QueryBuilder) BuildExistsQuery(object interface{}) (string, []interface{}, error) {
+	metadata, tableName, err := builder.getMetadataAndTableName(object)
+	if err != nil {
+		return "", nil, err
 	}
-	typ := reflect.TypeOf(object).Elem()
-	return inflection.Plural(typ.Name())
+	keyValues, err := builder.getPrimaryKeyValues(metadata, object)
+	if err != nil {
+		return "", nil, err
+	}
+	return strings.Join([]string{
+		"SELECT 1 FROM",
+		tableName,
+		"WHERE",
+		builder.buildKeyConditions(metadata, 1),
+	}, " "), keyValues, nil
+}
+
+// BuildFindQuery constructs and returns a SELECT query and arguments for every row of the passed object's (struct
+// pointer) table matching the passed conditions. Conditions are written using '?' placeholders, which are converted to
+// the dialect's placeholder style, e.g. BuildFindQuery(&person, "age > ? AND name <> ?", 30, "Frank").
+// An empty conditions string selects every row.
+func (builder *QueryBuilder) BuildFindQuery(object interface{}, conditions string, args ...interface{}) (string, []interface{}, error) {
+	criteria := NewCriteria()
+	if conditions != "" {
+		criteria = criteria.Where(conditions, args...)
+	}
+	return builder.BuildCriteriaQuery(object, criteria)
+}
+
+// GetAutoIncrementPointer returns a pointer to the auto-increment field of the passed object (struct pointer), if any.
+func (builder *QueryBuilder) GetAutoIncrementPointer(object interface{}) (interface{}, bool) {
+	metadata, err := GetMetadata(reflect.TypeOf(object))
+	if err != nil || metadata.AutoIncrement == nil {
+		return nil, false
+	}
+	return metadata.AutoIncrement.Value(reflect.ValueOf(object).Elem()).Addr().Interface(), true
+}
+
+// SetAutoIncrementValue sets the auto-increment field of the passed object (struct pointer) to the passed id.
+// Objects without an auto-increment field are left untouched.
+func (builder *QueryBuilder) SetAutoIncrementValue(object interface{}, id int64) error {
+	metadata, err := GetMetadata(reflect.TypeOf(object))
+	if err != nil {
+		return err
+	}
+	if metadata.AutoIncrement == nil {
+		return nil
+	}
+	field := metadata.AutoIncrement.Value(reflect.ValueOf(object).Elem())
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		field.SetInt(id)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		field.SetUint(uint64(id))
+	default:
+		return errors.Errorf("Unable to set auto-increment field '%s' of kind '%s'.", metadata.AutoIncrement.Name, field.Kind())
+	}
+	return nil
+}
+
+// HasVersion reports whether the passed object (struct pointer) has a field tagged 'type:"version"'.
+func (builder *QueryBuilder) HasVersion(object interface{}) bool {
+	metadata, err := GetMetadata(reflect.TypeOf(object))
+	return err == nil && metadata.Version != nil
 }
 
-func (builder *QueryBuilder) getColumnNamesAndValues(object interface{}, insertion bool) ([]string, []interface{}) {
-	typ := reflect.TypeOf(object).Elem()
+// IncrementVersion increments the version field of the passed object (struct pointer) to match the row after a
+// successful update. Objects without a version field are left untouched.
+func (builder *QueryBuilder) IncrementVersion(object interface{}) error {
+	metadata, err := GetMetadata(reflect.TypeOf(object))
+	if err != nil {
+		return err
+	}
+	if metadata.Version == nil {
+		return nil
+	}
+	field := metadata.Version.Value(reflect.ValueOf(object).Elem())
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		field.SetInt(field.Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		field.SetUint(field.Uint() + 1)
+	default:
+		return errors.Errorf("Unable to increment version field '%s' of kind '%s'.", metadata.Version.Name, field.Kind())
+	}
+	return nil
+}
+
+// SetInsertedTimestamps writes the created_at timestamps from the passed insert query arguments (as returned by
+// BuildInsertQuery) back into the passed object (struct pointer).
+func (builder *QueryBuilder) SetInsertedTimestamps(object interface{}, args []interface{}) {
+	metadata, err := GetMetadata(reflect.TypeOf(object))
+	if err != nil {
+		return
+	}
 	val := reflect.ValueOf(object).Elem()
-	columnNames := make([]string, 0)
-	values := make([]interface{}, 0)
-	for i := 0; i < typ.NumField(); i++ {
-		structField := typ.Field(i)
-		if builder.fieldShouldBeInserted(structField, insertion) {
-			columnNames = append(columnNames, builder.getFieldName(structField))
-			values = append(values, builder.getFieldValue(structField, val.Field(i)))
+	index := 0
+	for _, field := range metadata.Fields {
+		if !field.insertable(true) {
+			continue
+		}
+		if field.CreatedAt && index < len(args) {
+			builder.setTimestamp(field.Value(val), args[index])
 		}
+		index++
+	}
+}
+
+// GetFieldPointers returns pointers to every mapped field of the passed object (struct pointer), in the same order as
+// the columns of BuildSelectQuery.
+func (builder *QueryBuilder) GetFieldPointers(object interface{}) ([]interface{}, error) {
+	metadata, err := GetMetadata(reflect.TypeOf(object))
+	if err != nil {
+		return nil, err
+	}
+	val := reflect.ValueOf(object).Elem()
+	ptrs := make([]interface{}, len(metadata.Fields))
+	for i, field := range metadata.Fields {
+		ptrs[i] = field.Value(val).Addr().Interface()
+	}
+	return ptrs, nil
+}
+
+func (builder *QueryBuilder) setTimestamp(field reflect.Value, arg interface{}) {
+	timestamp, ok := arg.(time.Time)
+	if !ok {
+		return
+	}
+	switch field.Interface().(type) {
+	case time.Time:
+		field.Set(reflect.ValueOf(timestamp))
+	case *time.Time:
+		field.Set(reflect.ValueOf(&timestamp))
 	}
-	return columnNames, values
 }
 
-func (builder *QueryBuilder) fieldShouldBeInserted(field reflect.StructField, insertion bool) bool {
-	switch field.Tag.Get(tagType) {
-	case tagTypeAutoIncrement:
-		return false
-	case tagTypeCreatedAt:
-		return insertion
-	case tagTypeUpdatedAt:
-		return !insertion
+func (builder *QueryBuilder) getMetadataAndTableName(object interface{}) (*Metadata, string, error) {
+	metadata, err := GetMetadata(reflect.TypeOf(object))
+	if err != nil {
+		return nil, "", err
 	}
-	return true
+	tableName, err := builder.getQuotedTableName(metadata, object)
+	if err != nil {
+		return nil, "", err
+	}
+	return metadata, tableName, nil
 }
 
-func (builder *QueryBuilder) getFieldName(structField reflect.StructField) string {
-	name := structField.Tag.Get(tagName)
-	if name != "" {
-		return name
+func (builder *QueryBuilder) getTableName(metadata *Metadata, object interface{}) string {
+	if namer, ok := object.(tableNamer); ok {
+		return namer.GetTableName()
 	}
-	return structField.Name
+	return metadata.TableName
+}
+
+func (builder *QueryBuilder) getQuotedTableName(metadata *Metadata, object interface{}) (string, error) {
+	return builder.quoteTableName(builder.getTableName(metadata, object))
 }
 
-func (builder *QueryBuilder) getFieldValue(structField reflect.StructField, value reflect.Value) interface{} {
-	switch structField.Tag.Get(tagType) {
-	case tagTypeCreatedAt, tagTypeUpdatedAt:
-		return time.Now()
+func (builder *QueryBuilder) quoteTableName(name string) (string, error) {
+	// Table names may be qualified with a schema (e.g. 'schema.table'), each part is quoted separately.
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if err := validateIdentifier(part); err != nil {
+			return "", errors.Wrapf(err, "Invalid table name '%s'.", name)
+		}
+		parts[i] = builder.dialect.QuoteIdentifier(part)
 	}
-	return value.Interface()
+	return strings.Join(parts, "."), nil
 }
 
-func (builder *QueryBuilder) getColumnNames(object interface{}) []string {
-	typ := reflect.TypeOf(object).Elem()
-	columnNames := make([]string, 0)
-	for i := 0; i < typ.NumField(); i++ {
-		columnNames = append(columnNames, builder.getFieldName(typ.Field(i)))
+func (builder *QueryBuilder) getColumnNamesAndValues(metadata *Metadata, object interface{}, insertion bool) ([]string, []interface{}) {
+	val := reflect.ValueOf(object).Elem()
+	now := time.Now()
+	columnNames := make([]string, 0, len(metadata.Fields))
+	values := make([]interface{}, 0, len(metadata.Fields))
+	for _, field := range metadata.Fields {
+		// Primary key columns identify the row being updated so are never part of the SET list.
+		if !insertion && field.Key {
+			continue
+		}
+		if !field.insertable(insertion) {
+			continue
+		}
+		columnNames = append(columnNames, builder.dialect.QuoteIdentifier(field.Column))
+		if field.CreatedAt || field.UpdatedAt {
+			values = append(values, now)
+		} else {
+			values = append(values, field.Value(val).Interface())
+		}
+	}
+	return columnNames, values
+}
+
+// getUpdateFields returns the fields written by an update (or an upsert which finds an existing row): the passed columns
+// (or every updatable column when none are passed) along with any updated_at and version fields.
+func (builder *QueryBuilder) getUpdateFields(metadata *Metadata, columns []string) ([]*Field, error) {
+	fields := make([]*Field, 0, len(metadata.Fields))
+	if len(columns) == 0 {
+		for _, field := range metadata.Fields {
+			if !field.Key && (field.insertable(false) || field.Version) {
+				fields = append(fields, field)
+			}
+		}
+		return fields, nil
+	}
+	for _, column := range columns {
+		field, ok := metadata.Column(column)
+		if !ok {
+			return nil, errors.Errorf("Unable to update column '%s' (no such column).", column)
+		}
+		if field.Key || !field.insertable(false) {
+			return nil, errors.Errorf("Unable to update column '%s' (column is not updatable).", column)
+		}
+		if !field.UpdatedAt {
+			fields = append(fields, field)
+		}
+	}
+	for _, field := range metadata.Fields {
+		if field.UpdatedAt || field.Version {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+func (builder *QueryBuilder) getColumnNames(metadata *Metadata) []string {
+	columnNames := make([]string, len(metadata.Fields))
+	for i, field := range metadata.Fields {
+		columnNames[i] = builder.dialect.QuoteIdentifier(field.Column)
 	}
 	return columnNames
 }
 
-func (builder *QueryBuilder) buildUpdateValues(columnNames []string) string {
-	str := ""
-	for _, columnName := range columnNames {
-		str += columnName + "=?,"
+func (builder *QueryBuilder) buildPlaceholders(start, count int) string {
+	placeholders := make([]string, count)
+	for i := range placeholders {
+		placeholders[i] = builder.dialect.Placeholder(start + i)
 	}
-	return str[:len(str)-1]
+	return strings.Join(placeholders, ",")
 }
 
-func (builder *QueryBuilder) getPrimaryKeyNameAndValue(object interface{}) (string, interface{}, error) {
-	typ := reflect.TypeOf(object).Elem()
-	for i := 0; i < typ.NumField(); i++ {
-		structField := typ.Field(i)
-		if builder.hasPrimaryKeyTag(structField) {
-			return builder.getFieldName(structField), reflect.ValueOf(object).Elem().Field(i).Interface(), nil
+// rebind replaces the '?' placeholders in the passed clause with the dialect's placeholders, numbered from start.
+// Question marks within quoted strings and identifiers are left untouched.
+func (builder *QueryBuilder) rebind(clause string, start int) string {
+	var quote rune
+	rebound := strings.Builder{}
+	position := start
+	for _, r := range clause {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '?':
+			rebound.WriteString(builder.dialect.Placeholder(position))
+			position++
+			continue
 		}
+		rebound.WriteRune(r)
 	}
-	// Attempt to default to any field with a name of 'Id' or a name tag of 'id'.
-	for i := 0; i < typ.NumField(); i++ {
-		structField := typ.Field(i)
-		name := builder.getFieldName(structField)
-		switch name {
-		case "Id", "id":
-			return name, reflect.ValueOf(object).Elem().Field(i).Interface(), nil
-		}
+	return rebound.String()
+}
+
+func (builder *QueryBuilder) getPrimaryKeyValues(metadata *Metadata, object interface{}) ([]interface{}, error) {
+	if len(metadata.Keys) == 0 {
+		return nil, ErrNoPrimaryKey
+	}
+	val := reflect.ValueOf(object).Elem()
+	values := make([]interface{}, len(metadata.Keys))
+	for i, field := range metadata.Keys {
+		values[i] = field.Value(val).Interface()
 	}
-	return "", nil, errors.Errorf("Unable to identify primary key (struct is missing a '%s:\"true\"' tag).", tagKey)
+	return values, nil
 }
 
-func (builder *QueryBuilder) hasPrimaryKeyTag(structField reflect.StructField) bool {
-	return structField.Tag.Get(tagKey) == "true"
+func (builder *QueryBuilder) buildKeyConditions(metadata *Metadata, start int) string {
+	conditions := make([]string, len(metadata.Keys))
+	for i, field := range metadata.Keys {
+		conditions[i] = builder.dialect.QuoteIdentifier(field.Column) + "=" + builder.dialect.Placeholder(start+i)
+	}
+	return strings.Join(conditions, " AND ")
 }
@@ -39,14 +39,20 @@ func (obj *objectWithNoKey) GetTableName() string {
 	return "objects"
 }
 
+type objectWithTimeValues struct {
+	Name      string    `name:"name"`
+	CreatedAt time.Time `name:"created_at" type:"created_at"`
+}
+
 func TestQueryBuilder_BuildInsertQuery(t *testing.T) {
 	t.Run("basic", func(t *testing.T) {
 		obj := object{
 			Name: "Test Object",
 		}
 		builder := NewQueryBuilder()
-		query, args := builder.BuildInsertQuery(&obj)
-		assert.Equal(t, `INSERT INTO objects (Id,Name,CreatedAt,UpdatedAt) VALUES (?,?,?,?)`, query)
+		query, args, err := builder.BuildInsertQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, "INSERT INTO `objects` (`Id`,`Name`,`CreatedAt`,`UpdatedAt`) VALUES (?,?,?,?)", query)
 		assert.Equal(t, []interface{}{0, "Test Object", (*time.Time)(nil), (*time.Time)(nil)}, args)
 	})
 	t.Run("tags", func(t *testing.T) {
@@ -54,8 +60,9 @@ func TestQueryBuilder_BuildInsertQuery(t *testing.T) {
 			Name: "Test Object",
 		}
 		builder := NewQueryBuilder()
-		query, args := builder.BuildInsertQuery(&obj)
-		assert.Equal(t, `INSERT INTO objects (name,created_at) VALUES (?,?)`, query)
+		query, args, err := builder.BuildInsertQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, "INSERT INTO `objects` (`name`,`created_at`) VALUES (?,?)", query)
 		if assert.Len(t, args, 2) {
 			assert.Equal(t, args[0], "Test Object")
 			assert.NotNil(t, args[1])
@@ -72,8 +79,8 @@ func TestQueryBuilder_BuildUpdateQuery(t *testing.T) {
 		builder := NewQueryBuilder()
 		query, args, err := builder.BuildUpdateQuery(&obj)
 		require.NoError(t, err)
-		assert.Equal(t, `UPDATE objects SET Id=?,Name=?,CreatedAt=?,UpdatedAt=? WHERE Id=?`, query)
-		assert.Equal(t, []interface{}{0, "Test Object", (*time.Time)(nil), (*time.Time)(nil), 0}, args)
+		assert.Equal(t, "UPDATE `objects` SET `Name`=?,`CreatedAt`=?,`UpdatedAt`=? WHERE `Id`=?", query)
+		assert.Equal(t, []interface{}{"Test Object", (*time.Time)(nil), (*time.Time)(nil), 0}, args)
 	})
 	t.Run("tags", func(t *testing.T) {
 		obj := objectWithTags{
@@ -83,7 +90,7 @@ func TestQueryBuilder_BuildUpdateQuery(t *testing.T) {
 		builder := NewQueryBuilder()
 		query, args, err := builder.BuildUpdateQuery(&obj)
 		require.NoError(t, err)
-		assert.Equal(t, `UPDATE objects SET name=?,updated_at=? WHERE id=?`, query)
+		assert.Equal(t, "UPDATE `objects` SET `name`=?,`updated_at`=? WHERE `id`=?", query)
 		if assert.Len(t, args, 3) {
 			assert.Equal(t, args[0], "Test Object")
 			assert.NotNil(t, args[1])
@@ -102,6 +109,66 @@ func TestQueryBuilder_BuildUpdateQuery(t *testing.T) {
 	})
 }
 
+func TestQueryBuilder_BuildUpdateColumnsQuery(t *testing.T) {
+	obj := person{
+		Name:  "Frank",
+		Age:   32,
+		Email: "frank@example.com",
+	}
+	builder := NewQueryBuilder()
+	query, args, err := builder.BuildUpdateColumnsQuery(&obj, "email")
+	require.NoError(t, err)
+	assert.Equal(t, "UPDATE `people` SET `email`=?,`updated_at`=? WHERE `name`=?", query)
+	if assert.Len(t, args, 3) {
+		assert.Equal(t, "frank@example.com", args[0])
+		assert.IsType(t, time.Time{}, args[1])
+		assert.Equal(t, "Frank", args[2])
+	}
+	_, _, err = builder.BuildUpdateColumnsQuery(&obj)
+	assert.Error(t, err)
+	_, _, err = builder.BuildUpdateColumnsQuery(&obj, "name")
+	assert.Error(t, err)
+	_, _, err = builder.BuildUpdateColumnsQuery(&obj, "missing")
+	assert.Error(t, err)
+}
+
+func TestQueryBuilder_BuildUpdateMapQuery(t *testing.T) {
+	obj := objectWithVersion{
+		Id:      1,
+		Name:    "Test Object",
+		Version: 3,
+	}
+	t.Run("basic", func(t *testing.T) {
+		builder := NewQueryBuilder(WithDialect(PostgreSQL))
+		query, args, err := builder.BuildUpdateMapQuery(&obj, map[string]interface{}{"name": "Renamed Object"})
+		require.NoError(t, err)
+		assert.Equal(t, `UPDATE "objects" SET "name"=$1,"version"="version"+1 WHERE "id"=$2 AND "version"=$3`, query)
+		assert.Equal(t, []interface{}{"Renamed Object", 1, 3}, args)
+	})
+	t.Run("sorted", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		query, args, err := builder.BuildUpdateMapQuery(&person{Name: "Frank"}, map[string]interface{}{
+			"email": "frank@example.com",
+			"age":   33,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "UPDATE `people` SET `age`=?,`email`=?,`updated_at`=? WHERE `name`=?", query)
+		if assert.Len(t, args, 4) {
+			assert.Equal(t, []interface{}{33, "frank@example.com"}, args[:2])
+			assert.Equal(t, "Frank", args[3])
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		_, _, err := builder.BuildUpdateMapQuery(&obj, nil)
+		assert.Error(t, err)
+		_, _, err = builder.BuildUpdateMapQuery(&obj, map[string]interface{}{"missing": 1})
+		assert.Error(t, err)
+		_, _, err = builder.BuildUpdateMapQuery(&obj, map[string]interface{}{"id": 2})
+		assert.Error(t, err)
+	})
+}
+
 func TestQueryBuilder_BuildDeleteQuery(t *testing.T) {
 	t.Run("basic", func(t *testing.T) {
 		obj := object{
@@ -110,7 +177,7 @@ func TestQueryBuilder_BuildDeleteQuery(t *testing.T) {
 		builder := NewQueryBuilder()
 		query, args, err := builder.BuildDeleteQuery(&obj)
 		require.NoError(t, err)
-		assert.Equal(t, `DELETE FROM objects WHERE Id=?`, query)
+		assert.Equal(t, "DELETE FROM `objects` WHERE `Id`=?", query)
 		assert.Equal(t, []interface{}{1}, args)
 	})
 	t.Run("tags", func(t *testing.T) {
@@ -120,7 +187,7 @@ func TestQueryBuilder_BuildDeleteQuery(t *testing.T) {
 		builder := NewQueryBuilder()
 		query, args, err := builder.BuildDeleteQuery(&obj)
 		require.NoError(t, err)
-		assert.Equal(t, `DELETE FROM objects WHERE id=?`, query)
+		assert.Equal(t, "DELETE FROM `objects` WHERE `id`=?", query)
 		assert.Equal(t, []interface{}{1}, args)
 	})
 	t.Run("no primary key", func(t *testing.T) {
@@ -141,7 +208,7 @@ func TestQueryBuilder_BuildSelectQuery(t *testing.T) {
 		builder := NewQueryBuilder()
 		query, args, err := builder.BuildSelectQuery(&obj)
 		require.NoError(t, err)
-		assert.Equal(t, `SELECT Id,Name,CreatedAt,UpdatedAt FROM objects WHERE Id=?`, query)
+		assert.Equal(t, "SELECT `Id`,`Name`,`CreatedAt`,`UpdatedAt` FROM `objects` WHERE `Id`=?", query)
 		assert.Equal(t, []interface{}{0}, args)
 	})
 	t.Run("tags", func(t *testing.T) {
@@ -152,7 +219,7 @@ func TestQueryBuilder_BuildSelectQuery(t *testing.T) {
 		builder := NewQueryBuilder()
 		query, args, err := builder.BuildSelectQuery(&obj)
 		require.NoError(t, err)
-		assert.Equal(t, `SELECT id,name,created_at,updated_at FROM objects WHERE id=?`, query)
+		assert.Equal(t, "SELECT `id`,`name`,`created_at`,`updated_at` FROM `objects` WHERE `id`=?", query)
 		assert.Equal(t, []interface{}{1}, args)
 	})
 	t.Run("no primary key", func(t *testing.T) {
@@ -165,3 +232,412 @@ func TestQueryBuilder_BuildSelectQuery(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestQueryBuilder_BuildExistsQuery(t *testing.T) {
+	obj := objectWithTags{
+		Id:   1,
+		Name: "Test Object",
+	}
+	builder := NewQueryBuilder()
+	query, args, err := builder.BuildExistsQuery(&obj)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 1 FROM `objects` WHERE `id`=?", query)
+	assert.Equal(t, []interface{}{1}, args)
+	_, _, err = builder.BuildExistsQuery(&objectWithNoKey{})
+	assert.Error(t, err)
+}
+
+func TestQueryBuilder_SetAutoIncrementValue(t *testing.T) {
+	t.Run("int", func(t *testing.T) {
+		obj := objectWithTags{}
+		builder := NewQueryBuilder()
+		require.NoError(t, builder.SetAutoIncrementValue(&obj, 5))
+		assert.Equal(t, 5, obj.Id)
+	})
+	t.Run("int64", func(t *testing.T) {
+		obj := struct {
+			Id int64 `type:"auto-increment"`
+		}{}
+		builder := NewQueryBuilder()
+		require.NoError(t, builder.SetAutoIncrementValue(&obj, 5))
+		assert.Equal(t, int64(5), obj.Id)
+	})
+	t.Run("uint", func(t *testing.T) {
+		obj := struct {
+			Id uint `type:"auto-increment"`
+		}{}
+		builder := NewQueryBuilder()
+		require.NoError(t, builder.SetAutoIncrementValue(&obj, 5))
+		assert.Equal(t, uint(5), obj.Id)
+	})
+	t.Run("no auto-increment field", func(t *testing.T) {
+		obj := object{}
+		builder := NewQueryBuilder()
+		require.NoError(t, builder.SetAutoIncrementValue(&obj, 5))
+		assert.Equal(t, 0, obj.Id)
+	})
+	t.Run("unsupported kind", func(t *testing.T) {
+		obj := struct {
+			Id string `type:"auto-increment"`
+		}{}
+		builder := NewQueryBuilder()
+		require.Error(t, builder.SetAutoIncrementValue(&obj, 5))
+	})
+}
+
+func TestQueryBuilder_SetInsertedTimestamps(t *testing.T) {
+	t.Run("pointer", func(t *testing.T) {
+		obj := objectWithTags{
+			Name: "Test Object",
+		}
+		builder := NewQueryBuilder()
+		_, args, err := builder.BuildInsertQuery(&obj)
+		require.NoError(t, err)
+		builder.SetInsertedTimestamps(&obj, args)
+		if assert.NotNil(t, obj.CreatedAt) {
+			assert.Equal(t, args[1], *obj.CreatedAt)
+		}
+		assert.Nil(t, obj.UpdatedAt)
+	})
+	t.Run("value", func(t *testing.T) {
+		obj := objectWithTimeValues{
+			Name: "Test Object",
+		}
+		builder := NewQueryBuilder()
+		_, args, err := builder.BuildInsertQuery(&obj)
+		require.NoError(t, err)
+		builder.SetInsertedTimestamps(&obj, args)
+		assert.Equal(t, args[1], obj.CreatedAt)
+	})
+}
+
+type objectWithReservedNames struct {
+	Id    int    `name:"key" key:"true"`
+	Order string `name:"order"`
+}
+
+type objectWithMaliciousTableName struct {
+	Id int `name:"id"`
+}
+
+func (obj *objectWithMaliciousTableName) GetTableName() string {
+	return "objects` WHERE 1=1; DROP TABLE objects; --"
+}
+
+type objectWithSchema struct {
+	Id int `name:"id"`
+}
+
+func (obj *objectWithSchema) GetTableName() string {
+	return "test.objects"
+}
+
+type objectWithInvalidName struct {
+	Id   int    `name:"id"`
+	Name string `name:"na\x00me"`
+}
+
+func TestQueryBuilder_QuoteIdentifiers(t *testing.T) {
+	t.Run("reserved words", func(t *testing.T) {
+		obj := objectWithReservedNames{
+			Id:    1,
+			Order: "first",
+		}
+		builder := NewQueryBuilder()
+		query, _, err := builder.BuildSelectQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT `key`,`order` FROM `objectWithReservedNames` WHERE `key`=?", query)
+	})
+	t.Run("malicious table name", func(t *testing.T) {
+		obj := objectWithMaliciousTableName{
+			Id: 1,
+		}
+		builder := NewQueryBuilder()
+		query, _, err := builder.BuildDeleteQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, "DELETE FROM `objects`` WHERE 1=1; DROP TABLE objects; --` WHERE `id`=?", query)
+	})
+	t.Run("schema", func(t *testing.T) {
+		obj := objectWithSchema{
+			Id: 1,
+		}
+		builder := NewQueryBuilder(WithDialect(PostgreSQL))
+		query, _, err := builder.BuildDeleteQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, `DELETE FROM "test"."objects" WHERE "id"=$1`, query)
+	})
+	t.Run("invalid column name", func(t *testing.T) {
+		obj := objectWithInvalidName{
+			Id: 1,
+		}
+		builder := NewQueryBuilder()
+		_, _, err := builder.BuildInsertQuery(&obj)
+		require.Error(t, err)
+		_, _, err = builder.BuildSelectQuery(&obj)
+		require.Error(t, err)
+	})
+}
+
+type objectWithCompositeKey struct {
+	UserId int    `name:"user_id" key:"true"`
+	RoleId int    `name:"role_id" key:"true"`
+	Note   string `name:"note"`
+}
+
+func (obj *objectWithCompositeKey) GetTableName() string {
+	return "user_roles"
+}
+
+type objectWithOnlyKeys struct {
+	UserId int `name:"user_id" key:"true"`
+	RoleId int `name:"role_id" key:"true"`
+}
+
+func TestQueryBuilder_CompositeKey(t *testing.T) {
+	obj := objectWithCompositeKey{
+		UserId: 1,
+		RoleId: 2,
+		Note:   "Test Note",
+	}
+	t.Run("update", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		query, args, err := builder.BuildUpdateQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, "UPDATE `user_roles` SET `note`=? WHERE `user_id`=? AND `role_id`=?", query)
+		assert.Equal(t, []interface{}{"Test Note", 1, 2}, args)
+	})
+	t.Run("update postgres", func(t *testing.T) {
+		builder := NewQueryBuilder(WithDialect(PostgreSQL))
+		query, args, err := builder.BuildUpdateQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, `UPDATE "user_roles" SET "note"=$1 WHERE "user_id"=$2 AND "role_id"=$3`, query)
+		assert.Equal(t, []interface{}{"Test Note", 1, 2}, args)
+	})
+	t.Run("delete", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		query, args, err := builder.BuildDeleteQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, "DELETE FROM `user_roles` WHERE `user_id`=? AND `role_id`=?", query)
+		assert.Equal(t, []interface{}{1, 2}, args)
+	})
+	t.Run("select", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		query, args, err := builder.BuildSelectQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT `user_id`,`role_id`,`note` FROM `user_roles` WHERE `user_id`=? AND `role_id`=?", query)
+		assert.Equal(t, []interface{}{1, 2}, args)
+	})
+	t.Run("insert", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		query, args, err := builder.BuildInsertQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, "INSERT INTO `user_roles` (`user_id`,`role_id`,`note`) VALUES (?,?,?)", query)
+		assert.Equal(t, []interface{}{1, 2, "Test Note"}, args)
+	})
+	t.Run("update only keys", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		_, _, err := builder.BuildUpdateQuery(&objectWithOnlyKeys{UserId: 1, RoleId: 2})
+		require.Error(t, err)
+	})
+}
+
+func TestQueryBuilder_BuildFindQuery(t *testing.T) {
+	t.Run("basic", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		query, args, err := builder.BuildFindQuery(&objectWithTags{}, "name = ? AND id > ?", "Test Object", 1)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT `id`,`name`,`created_at`,`updated_at` FROM `objects` WHERE name = ? AND id > ?", query)
+		assert.Equal(t, []interface{}{"Test Object", 1}, args)
+	})
+	t.Run("no conditions", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		query, args, err := builder.BuildFindQuery(&objectWithTags{}, "")
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT `id`,`name`,`created_at`,`updated_at` FROM `objects`", query)
+		assert.Empty(t, args)
+	})
+	t.Run("postgres", func(t *testing.T) {
+		builder := NewQueryBuilder(WithDialect(PostgreSQL))
+		query, args, err := builder.BuildFindQuery(&objectWithTags{}, "name = ? AND note <> '?' AND id > ?", "Test Object", 1)
+		require.NoError(t, err)
+		assert.Equal(t, `SELECT "id","name","created_at","updated_at" FROM "objects" WHERE name = $1 AND note <> '?' AND id > $2`, query)
+		assert.Equal(t, []interface{}{"Test Object", 1}, args)
+	})
+}
+
+type objectWithIgnoredFields struct {
+	Id       int     `name:"id" type:"auto-increment" key:"true"`
+	Name     string  `name:"name"`
+	Total    float64 `name:"total" readonly:"true"`
+	Computed string  `name:"-"`
+	cache    string
+}
+
+func (obj *objectWithIgnoredFields) GetTableName() string {
+	return "objects"
+}
+
+func TestQueryBuilder_IgnoredFields(t *testing.T) {
+	obj := objectWithIgnoredFields{
+		Id:       1,
+		Name:     "Test Object",
+		Total:    9.5,
+		Computed: "Computed",
+		cache:    "cached",
+	}
+	t.Run("insert", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		query, args, err := builder.BuildInsertQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, "INSERT INTO `objects` (`name`) VALUES (?)", query)
+		assert.Equal(t, []interface{}{"Test Object"}, args)
+	})
+	t.Run("update", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		query, args, err := builder.BuildUpdateQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, "UPDATE `objects` SET `name`=? WHERE `id`=?", query)
+		assert.Equal(t, []interface{}{"Test Object", 1}, args)
+	})
+	t.Run("select", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		query, args, err := builder.BuildSelectQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT `id`,`name`,`total` FROM `objects` WHERE `id`=?", query)
+		assert.Equal(t, []interface{}{1}, args)
+	})
+	t.Run("field pointers", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		ptrs, err := builder.GetFieldPointers(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{&obj.Id, &obj.Name, &obj.Total}, ptrs)
+	})
+}
+
+type Timestamps struct {
+	CreatedAt *time.Time `name:"created_at" type:"created_at"`
+	UpdatedAt *time.Time `name:"updated_at" type:"updated_at"`
+}
+
+type audit struct {
+	ModifiedBy string `name:"modified_by"`
+}
+
+type Address struct {
+	Street string `name:"street"`
+	City   string `name:"city"`
+}
+
+type objectWithEmbeddedStructs struct {
+	Id             int     `name:"id" type:"auto-increment" key:"true"`
+	Name           string  `name:"name"`
+	BillingAddress Address `prefix:"billing_"`
+	Timestamps
+	audit
+}
+
+func (obj *objectWithEmbeddedStructs) GetTableName() string {
+	return "objects"
+}
+
+func TestQueryBuilder_EmbeddedStructs(t *testing.T) {
+	obj := objectWithEmbeddedStructs{
+		Id:             1,
+		Name:           "Test Object",
+		BillingAddress: Address{Street: "1 Test Street", City: "Testville"},
+		audit:          audit{ModifiedBy: "tester"},
+	}
+	t.Run("insert", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		query, args, err := builder.BuildInsertQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, "INSERT INTO `objects` (`name`,`billing_street`,`billing_city`,`created_at`,`modified_by`) VALUES (?,?,?,?,?)", query)
+		if assert.Len(t, args, 5) {
+			assert.Equal(t, []interface{}{"Test Object", "1 Test Street", "Testville"}, args[:3])
+			assert.IsType(t, time.Time{}, args[3])
+			assert.Equal(t, "tester", args[4])
+		}
+		builder.SetInsertedTimestamps(&obj, args)
+		assert.NotNil(t, obj.CreatedAt)
+	})
+	t.Run("update", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		query, _, err := builder.BuildUpdateQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, "UPDATE `objects` SET `name`=?,`billing_street`=?,`billing_city`=?,`updated_at`=?,`modified_by`=? WHERE `id`=?", query)
+	})
+	t.Run("select", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		query, _, err := builder.BuildSelectQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT `id`,`name`,`billing_street`,`billing_city`,`created_at`,`updated_at`,`modified_by` FROM `objects` WHERE `id`=?", query)
+		ptrs, err := builder.GetFieldPointers(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{&obj.Id, &obj.Name, &obj.BillingAddress.Street, &obj.BillingAddress.City, &obj.CreatedAt, &obj.UpdatedAt, &obj.ModifiedBy}, ptrs)
+	})
+}
+
+type objectWithVersion struct {
+	Id      int    `name:"id" key:"true"`
+	Name    string `name:"name"`
+	Version int    `name:"version" type:"version"`
+}
+
+func (obj *objectWithVersion) GetTableName() string {
+	return "objects"
+}
+
+type objectWithOnlyVersion struct {
+	Id      int `name:"id" key:"true"`
+	Version int `name:"version" type:"version"`
+}
+
+func (obj *objectWithOnlyVersion) GetTableName() string {
+	return "objects"
+}
+
+func TestQueryBuilder_Version(t *testing.T) {
+	obj := objectWithVersion{
+		Id:      1,
+		Name:    "Test Object",
+		Version: 3,
+	}
+	t.Run("insert", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		query, args, err := builder.BuildInsertQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, "INSERT INTO `objects` (`id`,`name`,`version`) VALUES (?,?,?)", query)
+		assert.Equal(t, []interface{}{1, "Test Object", 3}, args)
+	})
+	t.Run("update", func(t *testing.T) {
+		builder := NewQueryBuilder(WithDialect(PostgreSQL))
+		query, args, err := builder.BuildUpdateQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, `UPDATE "objects" SET "name"=$1,"version"="version"+1 WHERE "id"=$2 AND "version"=$3`, query)
+		assert.Equal(t, []interface{}{"Test Object", 1, 3}, args)
+	})
+	t.Run("update version only", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		query, args, err := builder.BuildUpdateQuery(&objectWithOnlyVersion{Id: 1, Version: 3})
+		require.NoError(t, err)
+		assert.Equal(t, "UPDATE `objects` SET `version`=`version`+1 WHERE `id`=? AND `version`=?", query)
+		assert.Equal(t, []interface{}{1, 3}, args)
+	})
+	t.Run("upsert", func(t *testing.T) {
+		builder := NewQueryBuilder(WithDialect(PostgreSQL))
+		query, _, err := builder.BuildUpsertQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, `INSERT INTO "objects" ("id","name","version") VALUES ($1,$2,$3) `+
+			`ON CONFLICT ("id") DO UPDATE SET "name"=EXCLUDED."name","version"="objects"."version"+1`, query)
+		_, _, err = builder.BuildUpsertQuery(&obj, "version")
+		assert.Error(t, err)
+	})
+	t.Run("increment", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		clone := obj
+		assert.True(t, builder.HasVersion(&clone))
+		require.NoError(t, builder.IncrementVersion(&clone))
+		assert.Equal(t, 4, clone.Version)
+		assert.False(t, builder.HasVersion(&objectWithTags{}))
+	})
+}
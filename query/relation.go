@@ -0,0 +1,125 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/jinzhu/inflection"
+	"github.com/pkg/errors"
+)
+
+// RelationKind describes how the rows of two tables are related.
+type RelationKind string
+
+const (
+	// BelongsTo relates a row to the row of another table referenced by one of its columns, e.g. an order's customer
+	// referenced by the order's customer_id column.
+	BelongsTo RelationKind = "belongs-to"
+	// HasOne relates a row to the single row of another table referencing it, e.g. a customer's profile whose
+	// customer_id column references the customer.
+	HasOne RelationKind = "has-one"
+	// HasMany relates a row to every row of another table referencing it, e.g. an order's line items whose order_id
+	// column references the order.
+	HasMany RelationKind = "has-many"
+	// ManyToMany relates a row to every row of another table linked to it by a join table, e.g. a user's roles linked
+	// by the user_id and role_id columns of a user_roles table.
+	ManyToMany RelationKind = "many-to-many"
+)
+
+// Relation describes a struct field tagged with 'rel' which holds the related rows of another table rather than
+// mapping to a column. Relation fields are ignored by every query built for their parent struct.
+type Relation struct {
+	// Name is the name of the struct field.
+	Name string
+	// Index is the index sequence of the field for use with reflect.Value.FieldByIndex.
+	Index []int
+	// Kind is the kind of relation, taken from the 'rel' tag.
+	Kind RelationKind
+	// Type is the struct type of the related rows.
+	Type reflect.Type
+	// FieldType is the type of the struct field: a struct or struct pointer for BelongsTo and HasOne, and a slice of
+	// structs or struct pointers for HasMany.
+	FieldType reflect.Type
+	// ForeignKey is the column referencing the other table, taken from the 'fk' tag. It belongs to the parent struct's
+	// table for BelongsTo, to the join table for ManyToMany (referencing the parent) and to the related table otherwise.
+	// It defaults to the singular of the parent's table name followed by '_id' for ManyToMany, e.g. 'user_id'.
+	ForeignKey string
+	// JoinTable is the table linking the rows of a ManyToMany relation, taken from the 'join' tag.
+	JoinTable string
+	// References is the column of the join table referencing the related table of a ManyToMany relation, taken from the
+	// 'ref' tag. It defaults to the singular of the related table name followed by '_id', e.g. 'role_id'.
+	References string
+}
+
+// Relation returns the relation held by the struct field with the passed name.
+func (metadata *Metadata) Relation(name string) (*Relation, bool) {
+	relation, ok := metadata.relations[name]
+	return relation, ok
+}
+
+// newRelation returns the relation described by the tags of the passed field of the passed parent struct type.
+func newRelation(parent reflect.Type, structField reflect.StructField, index []int, name string) (*Relation, error) {
+	relation := &Relation{
+		Name:       name,
+		Index:      index,
+		Kind:       RelationKind(structField.Tag.Get(tagRelation)),
+		FieldType:  structField.Type,
+		ForeignKey: structField.Tag.Get(tagForeignKey),
+		JoinTable:  structField.Tag.Get(tagJoinTable),
+		References: structField.Tag.Get(tagReferences),
+	}
+	typ := structField.Type
+	slice := typ.Kind() == reflect.Slice
+	if slice {
+		typ = typ.Elem()
+	}
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, errors.Errorf("Unable to map relation '%s' of type '%s' (expected a struct, struct pointer or slice of either).", name, structField.Type)
+	}
+	relation.Type = typ
+	switch relation.Kind {
+	case BelongsTo, HasOne:
+		if slice {
+			return nil, errors.Errorf("Unable to map %s relation '%s' to a slice.", relation.Kind, name)
+		}
+	case HasMany, ManyToMany:
+		if !slice {
+			return nil, errors.Errorf("Unable to map %s relation '%s' (expected a slice).", relation.Kind, name)
+		}
+	default:
+		return nil, errors.Errorf("Unable to map relation '%s' of unknown kind '%s'.", name, relation.Kind)
+	}
+	if relation.Kind == ManyToMany {
+		if relation.ForeignKey == "" {
+			relation.ForeignKey = getJoinColumn(parent)
+		}
+		if relation.References == "" {
+			relation.References = getJoinColumn(typ)
+		}
+	}
+	if err := validateIdentifier(relation.ForeignKey); err != nil {
+		return nil, errors.Wrapf(err, "Invalid foreign key for relation '%s' (missing '%s' tag?).", name, tagForeignKey)
+	}
+	if relation.Kind == ManyToMany {
+		if err := validateIdentifier(relation.JoinTable); err != nil {
+			return nil, errors.Wrapf(err, "Invalid join table for relation '%s' (missing '%s' tag?).", name, tagJoinTable)
+		}
+		if err := validateIdentifier(relation.References); err != nil {
+			return nil, errors.Wrapf(err, "Invalid join table reference for relation '%s' (missing '%s' tag?).", name, tagReferences)
+		}
+	}
+	return relation, nil
+}
+
+// getJoinColumn returns the default name of the join table column referencing the table of the passed struct type: the
+// singular of its table name, without any schema, followed by '_id'.
+func getJoinColumn(typ reflect.Type) string {
+	tableName := inflection.Plural(typ.Name())
+	if namer, ok := reflect.New(typ).Interface().(tableNamer); ok {
+		tableName = namer.GetTableName()
+	}
+	return inflection.Singular(tableName[strings.LastIndex(tableName, ".")+1:]) + "_id"
+}
@@ -0,0 +1,89 @@
+package query_test
+
+import (
+	. "github.com/dtucker2/database/query"
+
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type customer struct {
+	Id     int      `name:"id" key:"true"`
+	Name   string   `name:"name"`
+	Orders []*order `rel:"has-many" fk:"customer_id"`
+}
+
+type order struct {
+	Id         int        `name:"id" key:"true"`
+	CustomerId int        `name:"customer_id"`
+	Customer   *customer  `rel:"belongs-to" fk:"customer_id"`
+	LineItems  []lineItem `rel:"has-many" fk:"order_id"`
+}
+
+type lineItem struct {
+	Id      int `name:"id" key:"true"`
+	OrderId int `name:"order_id"`
+}
+
+func TestMetadata_Relations(t *testing.T) {
+	t.Run("basic", func(t *testing.T) {
+		metadata, err := GetMetadata(reflect.TypeOf(order{}))
+		require.NoError(t, err)
+		assert.Len(t, metadata.Fields, 2)
+		require.Len(t, metadata.Relations, 2)
+		relation, ok := metadata.Relation("Customer")
+		require.True(t, ok)
+		assert.Equal(t, BelongsTo, relation.Kind)
+		assert.Equal(t, reflect.TypeOf(customer{}), relation.Type)
+		assert.Equal(t, "customer_id", relation.ForeignKey)
+		relation, ok = metadata.Relation("LineItems")
+		require.True(t, ok)
+		assert.Equal(t, HasMany, relation.Kind)
+		assert.Equal(t, reflect.TypeOf(lineItem{}), relation.Type)
+		_, ok = metadata.Relation("CustomerId")
+		assert.False(t, ok)
+	})
+	t.Run("queries", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		query, _, err := builder.BuildSelectQuery(&order{Id: 1})
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT `id`,`customer_id` FROM `orders` WHERE `id`=?", query)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := GetMetadata(reflect.TypeOf(struct {
+			Items []lineItem `rel:"has-one" fk:"order_id"`
+		}{}))
+		assert.Error(t, err)
+		_, err = GetMetadata(reflect.TypeOf(struct {
+			Item lineItem `rel:"has-many" fk:"order_id"`
+		}{}))
+		assert.Error(t, err)
+		_, err = GetMetadata(reflect.TypeOf(struct {
+			Item lineItem `rel:"has-one"`
+		}{}))
+		assert.Error(t, err)
+		_, err = GetMetadata(reflect.TypeOf(struct {
+			Item lineItem `rel:"owns" fk:"order_id"`
+		}{}))
+		assert.Error(t, err)
+		_, err = GetMetadata(reflect.TypeOf(struct {
+			Items []int `rel:"has-many" fk:"order_id"`
+		}{}))
+		assert.Error(t, err)
+	})
+}
+
+func TestCriteria_WhereIn(t *testing.T) {
+	builder := NewQueryBuilder(WithDialect(PostgreSQL))
+	query, args, err := builder.BuildCriteriaQuery(&lineItem{}, NewCriteria().WhereIn("order_id", 1, 2, 3))
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT "id","order_id" FROM "lineItems" WHERE "order_id" IN ($1,$2,$3)`, query)
+	assert.Equal(t, []interface{}{1, 2, 3}, args)
+	query, args, err = builder.BuildCriteriaQuery(&lineItem{}, NewCriteria().WhereIn("order_id"))
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT "id","order_id" FROM "lineItems" WHERE 1=0`, query)
+	assert.Empty(t, args)
+}
@@ -0,0 +1,30 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+)
+
+// TableName returns the name of the passed struct pointer's table, unquoted and possibly qualified with a schema.
+func (builder *QueryBuilder) TableName(object interface{}) (string, error) {
+	metadata, err := GetMetadata(reflect.TypeOf(object))
+	if err != nil {
+		return "", err
+	}
+	return builder.getTableName(metadata, object), nil
+}
+
+// BuildColumnsQuery builds a query selecting the name, data type and nullability of each column of the passed struct
+// pointer's table, in order. No rows are selected if the table does not exist.
+func (builder *QueryBuilder) BuildColumnsQuery(object interface{}) (string, []interface{}, error) {
+	table, err := builder.TableName(object)
+	if err != nil {
+		return "", nil, err
+	}
+	schema := ""
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		schema, table = table[:i], table[i+1:]
+	}
+	query, args := builder.dialect.TableColumns(schema, table)
+	return query, args, nil
+}
@@ -0,0 +1,59 @@
+package query_test
+
+import (
+	. "github.com/dtucker2/database/query"
+
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryBuilder_BuildColumnsQuery(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		query   string
+		args    []interface{}
+	}{
+		{
+			dialect: MySQL,
+			query: "SELECT column_name, data_type, CASE WHEN is_nullable = 'YES' THEN 1 ELSE 0 END " +
+				"FROM information_schema.columns WHERE table_schema = COALESCE(NULLIF(?, ''), DATABASE()) " +
+				"AND table_name = ? ORDER BY ordinal_position",
+			args: []interface{}{"", "objects"},
+		},
+		{
+			dialect: PostgreSQL,
+			query: "SELECT column_name, data_type, CASE WHEN is_nullable = 'YES' THEN 1 ELSE 0 END " +
+				"FROM information_schema.columns WHERE table_schema = COALESCE(NULLIF($1, ''), current_schema()) " +
+				"AND table_name = $2 ORDER BY ordinal_position",
+			args: []interface{}{"", "objects"},
+		},
+		{
+			dialect: SQLite,
+			query: `SELECT name, type, "notnull" = 0 AND pk = 0 ` +
+				`FROM pragma_table_info(?, COALESCE(NULLIF(?, ''), 'main')) ORDER BY cid`,
+			args: []interface{}{"objects", ""},
+		},
+		{
+			dialect: SQLServer,
+			query: "SELECT column_name, data_type, CASE WHEN is_nullable = 'YES' THEN 1 ELSE 0 END " +
+				"FROM information_schema.columns WHERE table_schema = COALESCE(NULLIF(@p1, ''), SCHEMA_NAME()) " +
+				"AND table_name = @p2 ORDER BY ordinal_position",
+			args: []interface{}{"", "objects"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.dialect.Name(), func(t *testing.T) {
+			builder := NewQueryBuilder(WithDialect(test.dialect))
+			query, args, err := builder.BuildColumnsQuery(&objectWithTags{})
+			require.NoError(t, err)
+			assert.Equal(t, test.query, query)
+			assert.Equal(t, test.args, args)
+		})
+	}
+	query, args, err := NewQueryBuilder().BuildColumnsQuery(&objectWithSchema{})
+	require.NoError(t, err)
+	assert.Contains(t, query, "information_schema.columns")
+	assert.Equal(t, []interface{}{"test", "objects"}, args)
+}
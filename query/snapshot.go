@@ -0,0 +1,90 @@
+package query
+
+import (
+	"reflect"
+)
+
+// Snapshot holds the values of an object's updatable columns at a point in time, keyed by column name. It is compared
+// against the object by ChangedColumns to find the columns which need updating.
+type Snapshot map[string]interface{}
+
+// TakeSnapshot returns a Snapshot of the updatable columns of the passed object (struct pointer). Pointers are
+// dereferenced and byte slices copied, so that changes made through them are detected.
+func (builder *QueryBuilder) TakeSnapshot(object interface{}) (Snapshot, error) {
+	metadata, err := GetMetadata(reflect.TypeOf(object))
+	if err != nil {
+		return nil, err
+	}
+	val := reflect.ValueOf(object).Elem()
+	snapshot := make(Snapshot, len(metadata.Fields))
+	for _, field := range metadata.Fields {
+		if tracked(field) {
+			snapshot[field.Column] = snapshotValue(field.Value(val))
+		}
+	}
+	return snapshot, nil
+}
+
+// ChangedColumns returns the names of the updatable columns of the passed object (struct pointer) whose values differ
+// from the passed snapshot, in declaration order. The updated_at and version columns are never reported as they are
+// always written by updates.
+func (builder *QueryBuilder) ChangedColumns(object interface{}, snapshot Snapshot) ([]string, error) {
+	metadata, err := GetMetadata(reflect.TypeOf(object))
+	if err != nil {
+		return nil, err
+	}
+	val := reflect.ValueOf(object).Elem()
+	columns := make([]string, 0)
+	for _, field := range metadata.Fields {
+		if !tracked(field) {
+			continue
+		}
+		previous, ok := snapshot[field.Column]
+		if !ok || !reflect.DeepEqual(previous, snapshotValue(field.Value(val))) {
+			columns = append(columns, field.Column)
+		}
+	}
+	return columns, nil
+}
+
+// BuildUpdateChangesQuery constructs and returns an UPDATE query and arguments from the passed object (struct pointer)
+// which only writes the columns changed since the passed snapshot, along with any updated_at and version columns. An
+// empty query is returned when no column has changed.
+func (builder *QueryBuilder) BuildUpdateChangesQuery(object interface{}, snapshot Snapshot) (string, []interface{}, error) {
+	metadata, tableName, err := builder.getMetadataAndTableName(object)
+	if err != nil {
+		return "", nil, err
+	}
+	keyValues, err := builder.getPrimaryKeyValues(metadata, object)
+	if err != nil {
+		return "", nil, err
+	}
+	columns, err := builder.ChangedColumns(object, snapshot)
+	if err != nil || len(columns) == 0 {
+		return "", nil, err
+	}
+	fields, err := builder.getUpdateFields(metadata, columns)
+	if err != nil {
+		return "", nil, err
+	}
+	return builder.buildUpdateQuery(metadata, tableName, object, keyValues, fields, nil)
+}
+
+// tracked reports whether changes to the field's value are tracked by snapshots.
+func tracked(field *Field) bool {
+	return !field.Key && !field.UpdatedAt && !field.Version && field.insertable(false)
+}
+
+// snapshotValue returns a copy of the passed value which is unaffected by later changes made through pointers.
+func snapshotValue(value reflect.Value) interface{} {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8 && !value.IsNil() {
+		return append([]byte(nil), value.Bytes()...)
+	}
+	return value.Interface()
+}
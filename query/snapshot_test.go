@@ -0,0 +1,76 @@
+package query_test
+
+import (
+	. "github.com/dtucker2/database/query"
+
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type objectWithNullableName struct {
+	Id   int     `name:"id" key:"true"`
+	Name *string `name:"name"`
+}
+
+func TestQueryBuilder_Snapshot(t *testing.T) {
+	builder := NewQueryBuilder()
+	t.Run("unchanged", func(t *testing.T) {
+		obj := person{Name: "Frank", Age: 32}
+		snapshot, err := builder.TakeSnapshot(&obj)
+		require.NoError(t, err)
+		columns, err := builder.ChangedColumns(&obj, snapshot)
+		require.NoError(t, err)
+		assert.Empty(t, columns)
+	})
+	t.Run("changed", func(t *testing.T) {
+		obj := person{Name: "Frank", Age: 32, Email: "frank@example.com"}
+		snapshot, err := builder.TakeSnapshot(&obj)
+		require.NoError(t, err)
+		obj.Age = 33
+		obj.Email = "frank@example.org"
+		columns, err := builder.ChangedColumns(&obj, snapshot)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"age", "email"}, columns)
+	})
+	t.Run("pointers", func(t *testing.T) {
+		name := "Frank"
+		obj := objectWithNullableName{Id: 1, Name: &name}
+		snapshot, err := builder.TakeSnapshot(&obj)
+		require.NoError(t, err)
+		name = "Francis"
+		columns, err := builder.ChangedColumns(&obj, snapshot)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"name"}, columns)
+		obj.Name = nil
+		columns, err = builder.ChangedColumns(&obj, snapshot)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"name"}, columns)
+	})
+}
+
+func TestQueryBuilder_BuildUpdateChangesQuery(t *testing.T) {
+	obj := person{
+		Name:  "Frank",
+		Age:   32,
+		Email: "frank@example.com",
+	}
+	builder := NewQueryBuilder()
+	snapshot, err := builder.TakeSnapshot(&obj)
+	require.NoError(t, err)
+	query, args, err := builder.BuildUpdateChangesQuery(&obj, snapshot)
+	require.NoError(t, err)
+	assert.Empty(t, query)
+	assert.Empty(t, args)
+	obj.Email = "frank@example.org"
+	query, args, err = builder.BuildUpdateChangesQuery(&obj, snapshot)
+	require.NoError(t, err)
+	assert.Equal(t, "UPDATE `people` SET `email`=?,`updated_at`=? WHERE `name`=?", query)
+	if assert.Len(t, args, 3) {
+		assert.Equal(t, "frank@example.org", args[0])
+		assert.IsType(t, time.Time{}, args[1])
+		assert.Equal(t, "Frank", args[2])
+	}
+}
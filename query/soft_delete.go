@@ -0,0 +1,97 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Unscoped returns a copy of the builder which ignores soft deletes: delete queries physically delete rows and select
+// queries include soft deleted rows.
+func (builder *QueryBuilder) Unscoped() *QueryBuilder {
+	clone := *builder
+	clone.unscoped = true
+	return &clone
+}
+
+// SoftDeletes reports whether delete queries built for the passed object (struct pointer) soft delete rows.
+func (builder *QueryBuilder) SoftDeletes(object interface{}) bool {
+	metadata, err := GetMetadata(reflect.TypeOf(object))
+	return err == nil && builder.softDeletes(metadata)
+}
+
+// BuildForceDeleteQuery constructs and returns a DELETE query and arguments from the passed object (struct pointer),
+// physically deleting the row even when the object supports soft deletes.
+func (builder *QueryBuilder) BuildForceDeleteQuery(object interface{}) (string, []interface{}, error) {
+	metadata, tableName, err := builder.getMetadataAndTableName(object)
+	if err != nil {
+		return "", nil, err
+	}
+	return builder.buildHardDeleteQuery(metadata, tableName, object)
+}
+
+// BuildRestoreQuery constructs and returns an UPDATE query and arguments which restores the soft deleted row of the
+// passed object (struct pointer) by setting its deleted_at column to NULL.
+func (builder *QueryBuilder) BuildRestoreQuery(object interface{}) (string, []interface{}, error) {
+	metadata, tableName, err := builder.getMetadataAndTableName(object)
+	if err != nil {
+		return "", nil, err
+	}
+	if metadata.DeletedAt == nil {
+		return "", nil, errors.Errorf("Unable to restore '%T' (struct is missing a '%s:\"%s\"' tag).", object, tagType, tagTypeDeletedAt)
+	}
+	keyValues, err := builder.getPrimaryKeyValues(metadata, object)
+	if err != nil {
+		return "", nil, err
+	}
+	return strings.Join([]string{
+		"UPDATE",
+		tableName,
+		"SET",
+		builder.dialect.QuoteIdentifier(metadata.DeletedAt.Column) + "=NULL",
+		"WHERE",
+		builder.buildKeyConditions(metadata, 1),
+	}, " "), keyValues, nil
+}
+
+// SetDeletedTimestamp writes the passed timestamp into the deleted_at field of the passed object (struct pointer), or
+// clears the field when the timestamp is nil. Objects without a deleted_at field are left untouched.
+func (builder *QueryBuilder) SetDeletedTimestamp(object interface{}, timestamp interface{}) {
+	metadata, err := GetMetadata(reflect.TypeOf(object))
+	if err != nil || metadata.DeletedAt == nil {
+		return
+	}
+	field := metadata.DeletedAt.Value(reflect.ValueOf(object).Elem())
+	if timestamp == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return
+	}
+	builder.setTimestamp(field, timestamp)
+}
+
+func (builder *QueryBuilder) softDeletes(metadata *Metadata) bool {
+	return metadata.DeletedAt != nil && !builder.unscoped
+}
+
+func (builder *QueryBuilder) buildSoftDeleteQuery(metadata *Metadata, tableName string, object interface{}) (string, []interface{}, error) {
+	keyValues, err := builder.getPrimaryKeyValues(metadata, object)
+	if err != nil {
+		return "", nil, err
+	}
+	return strings.Join([]string{
+		"UPDATE",
+		tableName,
+		"SET",
+		builder.dialect.QuoteIdentifier(metadata.DeletedAt.Column) + "=" + builder.dialect.Placeholder(1),
+		"WHERE",
+		builder.buildKeyConditions(metadata, 2),
+		"AND",
+		builder.buildNotDeletedCondition(metadata),
+	}, " "), append([]interface{}{time.Now()}, keyValues...), nil
+}
+
+func (builder *QueryBuilder) buildNotDeletedCondition(metadata *Metadata) string {
+	return builder.dialect.QuoteIdentifier(metadata.DeletedAt.Column) + " IS NULL"
+}
@@ -0,0 +1,100 @@
+package query_test
+
+import (
+	. "github.com/dtucker2/database/query"
+
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type objectWithSoftDelete struct {
+	Id        int        `name:"id" key:"true"`
+	Name      string     `name:"name"`
+	DeletedAt *time.Time `name:"deleted_at" type:"deleted_at"`
+}
+
+func (obj *objectWithSoftDelete) GetTableName() string {
+	return "objects"
+}
+
+func TestQueryBuilder_SoftDelete(t *testing.T) {
+	obj := objectWithSoftDelete{
+		Id:   1,
+		Name: "Test Object",
+	}
+	t.Run("delete", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		assert.True(t, builder.SoftDeletes(&obj))
+		query, args, err := builder.BuildDeleteQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, "UPDATE `objects` SET `deleted_at`=? WHERE `id`=? AND `deleted_at` IS NULL", query)
+		if assert.Len(t, args, 2) {
+			assert.IsType(t, time.Time{}, args[0])
+			assert.Equal(t, 1, args[1])
+		}
+	})
+	t.Run("force delete", func(t *testing.T) {
+		builder := NewQueryBuilder(WithDialect(PostgreSQL))
+		query, args, err := builder.BuildForceDeleteQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, `DELETE FROM "objects" WHERE "id"=$1`, query)
+		assert.Equal(t, []interface{}{1}, args)
+	})
+	t.Run("unscoped", func(t *testing.T) {
+		builder := NewQueryBuilder().Unscoped()
+		assert.False(t, builder.SoftDeletes(&obj))
+		query, _, err := builder.BuildDeleteQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, "DELETE FROM `objects` WHERE `id`=?", query)
+		query, _, err = builder.BuildSelectQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT `id`,`name`,`deleted_at` FROM `objects` WHERE `id`=?", query)
+	})
+	t.Run("restore", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		query, args, err := builder.BuildRestoreQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, "UPDATE `objects` SET `deleted_at`=NULL WHERE `id`=?", query)
+		assert.Equal(t, []interface{}{1}, args)
+		_, _, err = builder.BuildRestoreQuery(&objectWithTags{Id: 1})
+		assert.Error(t, err)
+	})
+	t.Run("select", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		query, _, err := builder.BuildSelectQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT `id`,`name`,`deleted_at` FROM `objects` WHERE `id`=? AND `deleted_at` IS NULL", query)
+	})
+	t.Run("insert and update", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		query, _, err := builder.BuildInsertQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, "INSERT INTO `objects` (`id`,`name`) VALUES (?,?)", query)
+		query, _, err = builder.BuildUpdateQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, "UPDATE `objects` SET `name`=? WHERE `id`=?", query)
+	})
+	t.Run("criteria", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		query, args, err := builder.BuildCriteriaQuery(&obj, NewCriteria().Where("name = ?", "a").OrWhere("name = ?", "b"))
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT `id`,`name`,`deleted_at` FROM `objects` WHERE ((name = ?) OR (name = ?)) AND `deleted_at` IS NULL", query)
+		assert.Equal(t, []interface{}{"a", "b"}, args)
+		query, _, err = builder.BuildCriteriaQuery(&obj, NewCriteria().WithTrashed())
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT `id`,`name`,`deleted_at` FROM `objects`", query)
+	})
+	t.Run("count", func(t *testing.T) {
+		builder := NewQueryBuilder(WithDialect(PostgreSQL))
+		query, args, err := builder.BuildCountQuery(&obj, NewCriteria().Where("name = ?", "a").OrderBy("name").Limit(5))
+		require.NoError(t, err)
+		assert.Equal(t, `SELECT COUNT(*) FROM "objects" WHERE (name = $1) AND "deleted_at" IS NULL`, query)
+		assert.Equal(t, []interface{}{"a"}, args)
+		query, _, err = builder.BuildCountQuery(&objectWithTags{}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, `SELECT COUNT(*) FROM "objects"`, query)
+	})
+}
@@ -0,0 +1,91 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// BuildUpsertQuery constructs and returns an INSERT query and arguments from the passed object (struct pointer) which
+// updates the existing row instead when one with the same primary key already exists. The created_at column of an
+// existing row is preserved, its updated_at column refreshed and its version column incremented. By default every
+// other column is overwritten, this can be restricted by passing the names of the columns to overwrite.
+// A non-zero auto-increment key is inserted in order to match the existing row. When it is zero, the existing row is
+// matched by the fields tagged 'unique:"true"' instead, an error being returned if there are none.
+func (builder *QueryBuilder) BuildUpsertQuery(object interface{}, columns ...string) (string, []interface{}, error) {
+	metadata, tableName, err := builder.getMetadataAndTableName(object)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(metadata.Keys) == 0 {
+		return "", nil, ErrNoPrimaryKey
+	}
+	overwrite, err := builder.getUpdateFields(metadata, columns)
+	if err != nil {
+		return "", nil, err
+	}
+	columnNames, args := builder.getColumnNamesAndValues(metadata, object, true)
+	conflictFields := metadata.Keys
+	if field := metadata.AutoIncrement; field != nil && field.Key {
+		if id := field.Value(reflect.ValueOf(object).Elem()); !id.IsZero() {
+			columnNames = append([]string{builder.dialect.QuoteIdentifier(field.Column)}, columnNames...)
+			args = append([]interface{}{id.Interface()}, args...)
+		} else {
+			// A generated id never conflicts with an existing row.
+			conflictFields = make([]*Field, 0, len(metadata.Fields))
+			for _, field := range metadata.Fields {
+				if field.Unique {
+					conflictFields = append(conflictFields, field)
+				}
+			}
+			if len(conflictFields) == 0 {
+				return "", nil, errors.Errorf("Unable to build upsert query (auto-increment field '%s' is zero and no unique field identifies the existing row).", field.Name)
+			}
+		}
+	}
+	assignments := make([]string, 0, len(overwrite)+1)
+	for _, field := range overwrite {
+		column := builder.dialect.QuoteIdentifier(field.Column)
+		if field.UpdatedAt {
+			args = append(args, time.Now())
+			assignments = append(assignments, column+"="+builder.dialect.Placeholder(len(args)))
+		} else if field.Version {
+			// The existing row's column is qualified by the table name as PostgreSQL rejects ambiguous references.
+			assignments = append(assignments, column+"="+tableName+"."+column+"+1")
+		} else {
+			assignments = append(assignments, column+"="+builder.dialect.InsertedValue(column))
+		}
+	}
+	if metadata.AutoIncrement != nil {
+		if assignment, ok := builder.dialect.ReportExistingId(builder.dialect.QuoteIdentifier(metadata.AutoIncrement.Column)); ok {
+			assignments = append(assignments, assignment)
+		}
+	}
+	keyColumns := make([]string, len(conflictFields))
+	for i, field := range conflictFields {
+		keyColumns[i] = builder.dialect.QuoteIdentifier(field.Column)
+	}
+	returning := metadata.AutoIncrement != nil && builder.dialect.SupportsReturning()
+	if len(assignments) == 0 && returning {
+		// Rows left untouched by 'DO NOTHING' are not returned, so a key column is assigned its own value instead.
+		assignments = append(assignments, keyColumns[0]+"="+builder.dialect.InsertedValue(keyColumns[0]))
+	}
+	onConflict, ok := builder.dialect.OnConflictUpdate(keyColumns, assignments)
+	if !ok {
+		return "", nil, errors.Errorf("Unable to build upsert query (not supported by %s).", builder.dialect.Name())
+	}
+	parts := []string{
+		"INSERT INTO",
+		tableName,
+		"(" + strings.Join(columnNames, ",") + ")",
+		"VALUES",
+		"(" + builder.buildPlaceholders(1, len(columnNames)) + ")",
+		onConflict,
+	}
+	if returning {
+		parts = append(parts, "RETURNING", builder.dialect.QuoteIdentifier(metadata.AutoIncrement.Column))
+	}
+	return strings.Join(parts, " "), args, nil
+}
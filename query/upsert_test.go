@@ -0,0 +1,158 @@
+package query_test
+
+import (
+	. "github.com/dtucker2/database/query"
+
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type person struct {
+	Name      string     `name:"name" key:"true"`
+	Age       int        `name:"age"`
+	Email     string     `name:"email"`
+	CreatedAt *time.Time `name:"created_at" type:"created_at"`
+	UpdatedAt *time.Time `name:"updated_at" type:"updated_at"`
+}
+
+func (obj *person) GetTableName() string {
+	return "people"
+}
+
+type member struct {
+	Id    int    `name:"id" type:"auto-increment"`
+	Email string `name:"email" unique:"true"`
+	Name  string `name:"name"`
+}
+
+func (obj *member) GetTableName() string {
+	return "members"
+}
+
+type visit struct {
+	Id        int        `name:"id" type:"auto-increment" key:"true"`
+	CreatedAt *time.Time `name:"created_at" type:"created_at"`
+}
+
+func TestQueryBuilder_BuildUpsertQuery(t *testing.T) {
+	obj := person{
+		Name:  "Frank",
+		Age:   32,
+		Email: "frank@example.com",
+	}
+	t.Run("mysql", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		query, args, err := builder.BuildUpsertQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, "INSERT INTO `people` (`name`,`age`,`email`,`created_at`) VALUES (?,?,?,?) "+
+			"ON DUPLICATE KEY UPDATE `age`=VALUES(`age`),`email`=VALUES(`email`),`updated_at`=?", query)
+		if assert.Len(t, args, 5) {
+			assert.Equal(t, []interface{}{"Frank", 32, "frank@example.com"}, args[:3])
+			assert.IsType(t, time.Time{}, args[3])
+			assert.IsType(t, time.Time{}, args[4])
+		}
+	})
+	t.Run("postgres", func(t *testing.T) {
+		builder := NewQueryBuilder(WithDialect(PostgreSQL))
+		query, args, err := builder.BuildUpsertQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, `INSERT INTO "people" ("name","age","email","created_at") VALUES ($1,$2,$3,$4) `+
+			`ON CONFLICT ("name") DO UPDATE SET "age"=EXCLUDED."age","email"=EXCLUDED."email","updated_at"=$5`, query)
+		assert.Len(t, args, 5)
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		builder := NewQueryBuilder(WithDialect(SQLite))
+		query, _, err := builder.BuildUpsertQuery(&obj)
+		require.NoError(t, err)
+		assert.Equal(t, `INSERT INTO "people" ("name","age","email","created_at") VALUES (?,?,?,?) `+
+			`ON CONFLICT ("name") DO UPDATE SET "age"=excluded."age","email"=excluded."email","updated_at"=?`, query)
+	})
+	t.Run("sql server", func(t *testing.T) {
+		builder := NewQueryBuilder(WithDialect(SQLServer))
+		_, _, err := builder.BuildUpsertQuery(&obj)
+		require.Error(t, err)
+	})
+	t.Run("columns", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		query, args, err := builder.BuildUpsertQuery(&obj, "email")
+		require.NoError(t, err)
+		assert.Equal(t, "INSERT INTO `people` (`name`,`age`,`email`,`created_at`) VALUES (?,?,?,?) "+
+			"ON DUPLICATE KEY UPDATE `email`=VALUES(`email`),`updated_at`=?", query)
+		assert.Len(t, args, 5)
+	})
+	t.Run("returning", func(t *testing.T) {
+		builder := NewQueryBuilder(WithDialect(PostgreSQL))
+		query, _, err := builder.BuildUpsertQuery(&objectWithCompositeKey{UserId: 1, RoleId: 2})
+		require.NoError(t, err)
+		assert.Equal(t, `INSERT INTO "user_roles" ("user_id","role_id","note") VALUES ($1,$2,$3) `+
+			`ON CONFLICT ("user_id","role_id") DO UPDATE SET "note"=EXCLUDED."note"`, query)
+		query, args, err := builder.BuildUpsertQuery(&objectWithTags{Id: 7, Name: "Test Object"})
+		require.NoError(t, err)
+		assert.Equal(t, `INSERT INTO "objects" ("id","name","created_at") VALUES ($1,$2,$3) `+
+			`ON CONFLICT ("id") DO UPDATE SET "name"=EXCLUDED."name","updated_at"=$4 RETURNING "id"`, query)
+		assert.Equal(t, []interface{}{7, "Test Object"}, args[:2])
+	})
+	t.Run("auto-increment", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		query, args, err := builder.BuildUpsertQuery(&objectWithTags{Id: 7, Name: "Test Object"})
+		require.NoError(t, err)
+		assert.Equal(t, "INSERT INTO `objects` (`id`,`name`,`created_at`) VALUES (?,?,?) "+
+			"ON DUPLICATE KEY UPDATE `name`=VALUES(`name`),`updated_at`=?,`id`=LAST_INSERT_ID(`id`)", query)
+		assert.Equal(t, []interface{}{7, "Test Object"}, args[:2])
+		// A new id is generated for a zero auto-increment field, the existing row is matched by its unique fields.
+		query, args, err = builder.BuildUpsertQuery(&member{Email: "frank@example.com", Name: "Frank"})
+		require.NoError(t, err)
+		assert.Equal(t, "INSERT INTO `members` (`email`,`name`) VALUES (?,?) "+
+			"ON DUPLICATE KEY UPDATE `email`=VALUES(`email`),`name`=VALUES(`name`),`id`=LAST_INSERT_ID(`id`)", query)
+		assert.Equal(t, []interface{}{"frank@example.com", "Frank"}, args)
+		builder = NewQueryBuilder(WithDialect(PostgreSQL))
+		query, _, err = builder.BuildUpsertQuery(&member{Email: "frank@example.com", Name: "Frank"})
+		require.NoError(t, err)
+		assert.Equal(t, `INSERT INTO "members" ("email","name") VALUES ($1,$2) ON CONFLICT ("email") `+
+			`DO UPDATE SET "email"=EXCLUDED."email","name"=EXCLUDED."name" RETURNING "id"`, query)
+		_, _, err = builder.BuildUpsertQuery(&objectWithTags{Name: "Test Object"})
+		assert.Error(t, err)
+	})
+	t.Run("only keys", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		query, _, err := builder.BuildUpsertQuery(&objectWithOnlyKeys{UserId: 1, RoleId: 2})
+		require.NoError(t, err)
+		assert.Equal(t, "INSERT INTO `objectWithOnlyKeys` (`user_id`,`role_id`) VALUES (?,?) "+
+			"ON DUPLICATE KEY UPDATE `user_id`=`user_id`", query)
+		builder = NewQueryBuilder(WithDialect(PostgreSQL))
+		query, _, err = builder.BuildUpsertQuery(&objectWithOnlyKeys{UserId: 1, RoleId: 2})
+		require.NoError(t, err)
+		assert.Equal(t, `INSERT INTO "objectWithOnlyKeys" ("user_id","role_id") VALUES ($1,$2) `+
+			`ON CONFLICT ("user_id","role_id") DO NOTHING`, query)
+	})
+	t.Run("only auto-increment key", func(t *testing.T) {
+		// The existing row must be updated, rather than left untouched, for its id to be returned.
+		builder := NewQueryBuilder(WithDialect(PostgreSQL))
+		query, _, err := builder.BuildUpsertQuery(&visit{Id: 3})
+		require.NoError(t, err)
+		assert.Equal(t, `INSERT INTO "visits" ("id","created_at") VALUES ($1,$2) `+
+			`ON CONFLICT ("id") DO UPDATE SET "id"=EXCLUDED."id" RETURNING "id"`, query)
+		builder = NewQueryBuilder(WithDialect(SQLite))
+		query, _, err = builder.BuildUpsertQuery(&visit{Id: 3})
+		require.NoError(t, err)
+		assert.Equal(t, `INSERT INTO "visits" ("id","created_at") VALUES (?,?) `+
+			`ON CONFLICT ("id") DO UPDATE SET "id"=excluded."id" RETURNING "id"`, query)
+	})
+	t.Run("invalid columns", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		_, _, err := builder.BuildUpsertQuery(&obj, "missing")
+		require.Error(t, err)
+		_, _, err = builder.BuildUpsertQuery(&obj, "name")
+		require.Error(t, err)
+		_, _, err = builder.BuildUpsertQuery(&obj, "created_at")
+		require.Error(t, err)
+	})
+	t.Run("no primary key", func(t *testing.T) {
+		builder := NewQueryBuilder()
+		_, _, err := builder.BuildUpsertQuery(&objectWithNoKey{})
+		require.Equal(t, ErrNoPrimaryKey, err)
+	})
+}
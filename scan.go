@@ -0,0 +1,72 @@
+package database
+
+import (
+	"database/sql"
+	"reflect"
+
+	"github.com/pkg/errors"
+
+	"github.com/dtucker2/database/query"
+)
+
+// scanRow scans the current row into the passed object (struct pointer), matching result columns to struct fields by
+// column name so the order of the columns and fields does not matter.
+func (db *Database) scanRow(rows *sql.Rows, columns []string, object interface{}) error {
+	ptrs, err := db.getColumnPointers(columns, object)
+	if err != nil {
+		return err
+	}
+	if err := rows.Scan(ptrs...); err != nil {
+		return errors.Wrap(err, "Failed to scan row.")
+	}
+	return nil
+}
+
+// scanJoinedRow scans the current row of a query built by BuildManyToManyQuery into the passed object (struct pointer),
+// matching columns by name as scanRow does, and returns the key of the parent the row is linked to.
+func (db *Database) scanJoinedRow(rows *sql.Rows, columns []string, object interface{}) (interface{}, error) {
+	owner := -1
+	for i, column := range columns {
+		if column == query.JoinedOwnerKeyColumn {
+			owner = i
+		}
+	}
+	if owner < 0 {
+		return nil, errors.Errorf("Unable to scan joined row (no '%s' column).", query.JoinedOwnerKeyColumn)
+	}
+	fieldColumns := append(append(make([]string, 0, len(columns)-1), columns[:owner]...), columns[owner+1:]...)
+	ptrs, err := db.getColumnPointers(fieldColumns, object)
+	if err != nil {
+		return nil, err
+	}
+	var ownerKey interface{}
+	ptrs = append(ptrs[:owner], append([]interface{}{&ownerKey}, ptrs[owner:]...)...)
+	if err := rows.Scan(ptrs...); err != nil {
+		return nil, errors.Wrap(err, "Failed to scan row.")
+	}
+	return ownerKey, nil
+}
+
+// getColumnPointers returns a pointer to the struct field mapped to each of the passed columns. Columns without a
+// settable field result in an error unless the Database discards unknown columns.
+func (db *Database) getColumnPointers(columns []string, object interface{}) ([]interface{}, error) {
+	metadata, err := query.GetMetadata(reflect.TypeOf(object))
+	if err != nil {
+		return nil, err
+	}
+	val := reflect.ValueOf(object).Elem()
+	ptrs := make([]interface{}, len(columns))
+	for i, column := range columns {
+		if field, ok := metadata.Column(column); ok {
+			if value := field.Value(val); value.CanSet() {
+				ptrs[i] = value.Addr().Interface()
+				continue
+			}
+		}
+		if !db.discardUnknownColumns {
+			return nil, errors.Errorf("Unable to scan column '%s' (no matching field in '%s').", column, metadata.Type)
+		}
+		ptrs[i] = new(interface{})
+	}
+	return ptrs, nil
+}
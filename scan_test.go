@@ -0,0 +1,132 @@
+package database_test
+
+import (
+	. "github.com/dtucker2/database"
+
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type objectWithUnexportedField struct {
+	Id       int    `name:"id" key:"true"`
+	Name     string `name:"name"`
+	Computed string `name:"-"`
+	cache    string
+}
+
+func (obj *objectWithUnexportedField) GetTableName() string {
+	return "objects"
+}
+
+type Address struct {
+	Street string `name:"street"`
+	City   string `name:"city"`
+}
+
+type Timestamps struct {
+	CreatedAt *time.Time `name:"created_at" type:"created_at"`
+	UpdatedAt *time.Time `name:"updated_at" type:"updated_at"`
+}
+
+type objectWithEmbeddedStructs struct {
+	Id             int     `name:"id" key:"true"`
+	BillingAddress Address `prefix:"billing_"`
+	Timestamps
+}
+
+func (obj *objectWithEmbeddedStructs) GetTableName() string {
+	return "objects"
+}
+
+func TestDatabase_Scan(t *testing.T) {
+	t.Run("reordered columns", func(t *testing.T) {
+		obj := objectWithTags{
+			Id: 1,
+		}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("SELECT .* FROM `objects` WHERE `id`=\\?").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"updated_at", "name", "id", "created_at"}).
+				AddRow((*time.Time)(nil), "Test Object", 1, (*time.Time)(nil)))
+		require.NoError(t, NewDatabase(db).Select(&obj))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, objectWithTags{Id: 1, Name: "Test Object"}, obj)
+	})
+	t.Run("ignored fields", func(t *testing.T) {
+		obj := objectWithUnexportedField{
+			Id:       1,
+			Computed: "Computed",
+			cache:    "cached",
+		}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("SELECT `id`,`name` FROM `objects` WHERE `id`=\\?").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"name", "id"}).
+				AddRow("Test Object", 1))
+		require.NoError(t, NewDatabase(db).Select(&obj))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, objectWithUnexportedField{Id: 1, Name: "Test Object", Computed: "Computed", cache: "cached"}, obj)
+	})
+	t.Run("unknown column", func(t *testing.T) {
+		obj := objectWithTags{
+			Id: 1,
+		}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("SELECT .* FROM `objects` WHERE `id`=\\?").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "extra"}).
+				AddRow(1, "Test Object", "Extra"))
+		err = NewDatabase(db).Select(&obj)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "extra")
+	})
+	t.Run("discard unknown columns", func(t *testing.T) {
+		objs := []objectWithTags{}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("SELECT .* FROM `objects`").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "extra"}).
+				AddRow(1, "Test Object", "Extra"))
+		require.NoError(t, NewDatabase(db, DiscardUnknownColumns()).Find(&objs))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, []objectWithTags{{Id: 1, Name: "Test Object"}}, objs)
+	})
+	t.Run("no rows", func(t *testing.T) {
+		obj := objectWithTags{
+			Id: 1,
+		}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("SELECT .* FROM `objects` WHERE `id`=\\?").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at", "updated_at"}))
+		require.Error(t, NewDatabase(db).Select(&obj))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("embedded structs", func(t *testing.T) {
+		obj := objectWithEmbeddedStructs{
+			Id: 1,
+		}
+		now := time.Now()
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("SELECT `id`,`billing_street`,`billing_city`,`created_at`,`updated_at` FROM `objects` WHERE `id`=\\?").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "billing_street", "billing_city", "created_at", "updated_at"}).
+				AddRow(1, "1 Test Street", "Testville", now, nil))
+		require.NoError(t, NewDatabase(db).Select(&obj))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, Address{Street: "1 Test Street", City: "Testville"}, obj.BillingAddress)
+		if assert.NotNil(t, obj.CreatedAt) {
+			assert.Equal(t, now, *obj.CreatedAt)
+		}
+		assert.Nil(t, obj.UpdatedAt)
+	})
+}
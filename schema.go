@@ -0,0 +1,360 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/dtucker2/database/query"
+)
+
+// DifferenceKind describes how a table differs from its struct.
+type DifferenceKind string
+
+const (
+	// MissingTable means the struct's table does not exist.
+	MissingTable DifferenceKind = "missing table"
+	// MissingColumn means a field's column does not exist.
+	MissingColumn DifferenceKind = "missing column"
+	// ExtraColumn means a column is not mapped to any field.
+	ExtraColumn DifferenceKind = "extra column"
+	// NullableColumn means a column accepts NULL but its field cannot hold NULL (e.g. a string rather than a *string),
+	// so selecting a NULL value fails.
+	NullableColumn DifferenceKind = "nullable column"
+	// IncompatibleType means a column's data type cannot store the values of its field's type.
+	IncompatibleType DifferenceKind = "incompatible type"
+)
+
+// SchemaDifference describes a single difference between a struct and its table, found by VerifySchema.
+type SchemaDifference struct {
+	// Kind is the kind of difference.
+	Kind DifferenceKind
+	// Table is the name of the table.
+	Table string
+	// Column is the name of the column, empty for a MissingTable.
+	Column string
+	// Field is the name of the struct field, empty for a MissingTable or ExtraColumn.
+	Field string
+	// FieldType is the type of the struct field, nil for a MissingTable or ExtraColumn.
+	FieldType reflect.Type
+	// DataType is the column's data type as reported by the database, empty for a MissingTable or MissingColumn.
+	DataType string
+}
+
+func (difference SchemaDifference) String() string {
+	switch difference.Kind {
+	case MissingTable:
+		return "table '" + difference.Table + "' does not exist"
+	case MissingColumn:
+		return "column '" + difference.Column + "' of table '" + difference.Table + "' does not exist (field '" +
+			difference.Field + "')"
+	case ExtraColumn:
+		return "column '" + difference.Column + "' of table '" + difference.Table + "' is not mapped to a field"
+	case NullableColumn:
+		return "column '" + difference.Column + "' of table '" + difference.Table + "' is nullable but field '" +
+			difference.Field + "' (" + difference.FieldType.String() + ") cannot hold NULL"
+	}
+	return "column '" + difference.Column + "' of table '" + difference.Table + "' has type '" + difference.DataType +
+		"' incompatible with field '" + difference.Field + "' (" + difference.FieldType.String() + ")"
+}
+
+// SchemaError is returned by VerifySchema when any table differs from its struct.
+type SchemaError struct {
+	// Differences holds every difference found, in the order of the structs passed and their fields.
+	Differences []SchemaDifference
+}
+
+func (err *SchemaError) Error() string {
+	differences := make([]string, len(err.Differences))
+	for i, difference := range err.Differences {
+		differences[i] = difference.String()
+	}
+	return "Schema does not match structs (" + strings.Join(differences, "; ") + ")."
+}
+
+// CreateTable constructs and executes a CREATE TABLE query on the database using only the passed pointer to a struct,
+// followed by a CREATE INDEX query for each index declared by its 'index' tags.
+func (db *Database) CreateTable(object interface{}) error {
+	return db.CreateTableContext(context.Background(), object)
+}
+
+// CreateTableContext is the same as CreateTable but executes the queries using the passed context.
+func (db *Database) CreateTableContext(ctx context.Context, object interface{}) error {
+	return db.createTable(ctx, db.DB, object)
+}
+
+// DropTable constructs and executes a DROP TABLE query on the database using only the passed pointer to a struct.
+func (db *Database) DropTable(object interface{}) error {
+	return db.DropTableContext(context.Background(), object)
+}
+
+// DropTableContext is the same as DropTable but executes the query using the passed context.
+func (db *Database) DropTableContext(ctx context.Context, object interface{}) error {
+	return db.dropTable(ctx, db.DB, object)
+}
+
+// VerifySchema compares the tables of the passed pointers to structs with the database within the transaction, e.g.
+// to check the result of migrations before committing them.
+func (tx *Tx) VerifySchema(models ...interface{}) error {
+	return tx.VerifySchemaContext(context.Background(), models...)
+}
+
+// VerifySchemaContext is the same as VerifySchema but executes the queries using the passed context.
+func (tx *Tx) VerifySchemaContext(ctx context.Context, models ...interface{}) error {
+	return tx.db.verifySchema(ctx, tx.Tx, models)
+}
+
+// CreateTable constructs and executes the CREATE TABLE and CREATE INDEX queries of the passed pointer to a struct
+// within the transaction. MySQL implicitly commits the transaction before executing them.
+func (tx *Tx) CreateTable(object interface{}) error {
+	return tx.CreateTableContext(context.Background(), object)
+}
+
+// CreateTableContext is the same as CreateTable but executes the queries using the passed context.
+func (tx *Tx) CreateTableContext(ctx context.Context, object interface{}) error {
+	return tx.db.createTable(ctx, tx.Tx, object)
+}
+
+// DropTable constructs and executes a DROP TABLE query using only the passed pointer to a struct within the
+// transaction. MySQL implicitly commits the transaction before executing it.
+func (tx *Tx) DropTable(object interface{}) error {
+	return tx.DropTableContext(context.Background(), object)
+}
+
+// DropTableContext is the same as DropTable but executes the query using the passed context.
+func (tx *Tx) DropTableContext(ctx context.Context, object interface{}) error {
+	return tx.db.dropTable(ctx, tx.Tx, object)
+}
+
+// VerifySchema compares the tables of the passed pointers to structs with the database, returning a *SchemaError
+// listing every missing table, missing or extra column, nullable column whose field cannot hold NULL and column whose
+// data type is incompatible with its field's type. It is intended for startup checks and tests, e.g.
+//
+//	if err := db.VerifySchema(&Person{}, &Order{}); err != nil {
+//		log.Fatal(err)
+//	}
+func (db *Database) VerifySchema(models ...interface{}) error {
+	return db.VerifySchemaContext(context.Background(), models...)
+}
+
+// VerifySchemaContext is the same as VerifySchema but executes the queries using the passed context.
+func (db *Database) VerifySchemaContext(ctx context.Context, models ...interface{}) error {
+	return db.verifySchema(ctx, db.DB, models)
+}
+
+func (db *Database) createTable(ctx context.Context, exec executor, object interface{}) error {
+	query, err := db.BuildCreateTableQuery(object)
+	if err != nil {
+		return err
+	}
+	indexQueries, err := db.BuildCreateIndexQueries(object)
+	if err != nil {
+		return err
+	}
+	for _, query := range append([]string{query}, indexQueries...) {
+		if _, err := exec.ExecContext(ctx, query); err != nil {
+			return newQueryError(err)
+		}
+	}
+	return nil
+}
+
+func (db *Database) dropTable(ctx context.Context, exec executor, object interface{}) error {
+	query, err := db.BuildDropTableQuery(object)
+	if err != nil {
+		return err
+	}
+	if _, err := exec.ExecContext(ctx, query); err != nil {
+		return newQueryError(err)
+	}
+	return nil
+}
+
+func (db *Database) verifySchema(ctx context.Context, exec executor, models []interface{}) error {
+	differences := make([]SchemaDifference, 0)
+	for _, model := range models {
+		modelDifferences, err := db.compareSchema(ctx, exec, model)
+		if err != nil {
+			return err
+		}
+		differences = append(differences, modelDifferences...)
+	}
+	if len(differences) > 0 {
+		return &SchemaError{Differences: differences}
+	}
+	return nil
+}
+
+// tableColumn is a column of a table, as reported by the database.
+type tableColumn struct {
+	name     string
+	dataType string
+	nullable bool
+}
+
+// compareSchema returns the differences between the passed struct pointer and its table.
+func (db *Database) compareSchema(ctx context.Context, exec executor, model interface{}) ([]SchemaDifference, error) {
+	metadata, err := query.GetMetadata(reflect.TypeOf(model))
+	if err != nil {
+		return nil, err
+	}
+	table, err := db.TableName(model)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := db.getTableColumns(ctx, exec, model)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return []SchemaDifference{{Kind: MissingTable, Table: table}}, nil
+	}
+	differences := make([]SchemaDifference, 0)
+	columnsByName := make(map[string]tableColumn, len(columns))
+	for _, column := range columns {
+		columnsByName[column.name] = column
+	}
+	for _, field := range metadata.Fields {
+		difference := SchemaDifference{Table: table, Column: field.Column, Field: field.Name, FieldType: field.Type}
+		column, ok := columnsByName[field.Column]
+		if !ok {
+			difference.Kind = MissingColumn
+			differences = append(differences, difference)
+			continue
+		}
+		difference.DataType = column.dataType
+		if column.nullable && !canHoldNull(field.Type) {
+			difference.Kind = NullableColumn
+			differences = append(differences, difference)
+		}
+		if !isCompatibleType(field.ValueType(), column.dataType) {
+			difference.Kind = IncompatibleType
+			differences = append(differences, difference)
+		}
+	}
+	for _, column := range columns {
+		if _, ok := metadata.Column(column.name); !ok {
+			differences = append(differences, SchemaDifference{
+				Kind:     ExtraColumn,
+				Table:    table,
+				Column:   column.name,
+				DataType: column.dataType,
+			})
+		}
+	}
+	return differences, nil
+}
+
+func (db *Database) getTableColumns(ctx context.Context, exec executor, model interface{}) ([]tableColumn, error) {
+	query, args, err := db.BuildColumnsQuery(model)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := exec.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, newQueryError(err)
+	}
+	defer rows.Close()
+	columns := make([]tableColumn, 0)
+	for rows.Next() {
+		var column tableColumn
+		if err := rows.Scan(&column.name, &column.dataType, &column.nullable); err != nil {
+			return nil, newQueryError(err)
+		}
+		columns = append(columns, column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, newQueryError(err)
+	}
+	return columns, nil
+}
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// canHoldNull reports whether a NULL value can be scanned into a field of the passed type.
+func canHoldNull(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		return true
+	}
+	return reflect.PtrTo(typ).Implements(scannerType)
+}
+
+// dataTypes lists the data types reported by each database, lower cased and without any size, by the category of
+// values they store.
+var dataTypes = map[string][]string{
+	"text": {
+		"char", "character", "varchar", "character varying", "nchar", "nvarchar", "text", "tinytext", "mediumtext",
+		"longtext", "ntext", "clob", "enum", "set", "citext", "uuid", "uniqueidentifier", "json", "jsonb", "xml",
+	},
+	"integer": {
+		"tinyint", "smallint", "mediumint", "int", "integer", "bigint", "int2", "int4", "int8", "serial",
+		"smallserial", "bigserial", "year",
+	},
+	"decimal": {
+		"decimal", "numeric", "money", "smallmoney",
+	},
+	"float": {
+		"float", "float4", "float8", "real", "double", "double precision",
+	},
+	"bool": {
+		"bool", "boolean", "bit",
+	},
+	"time": {
+		"date", "time", "datetime", "datetime2", "smalldatetime", "datetimeoffset", "timestamp", "timestamptz",
+		"timestamp without time zone", "timestamp with time zone",
+	},
+	"bytes": {
+		"binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob", "bytea", "image",
+	},
+}
+
+// dataTypeCategories maps each of the dataTypes to its category.
+var dataTypeCategories = func() map[string]string {
+	categories := make(map[string]string)
+	for category, types := range dataTypes {
+		for _, dataType := range types {
+			categories[dataType] = category
+		}
+	}
+	return categories
+}()
+
+// isCompatibleType reports whether a column of the passed data type can store the values of the passed Go type.
+// Unrecognised data types and Go types implementing sql.Scanner are assumed to be compatible.
+func isCompatibleType(typ reflect.Type, dataType string) bool {
+	dataType = strings.ToLower(strings.TrimSpace(dataType))
+	if i := strings.IndexAny(dataType, "( "); i >= 0 && dataTypeCategories[dataType] == "" {
+		// Remove sizes and attributes, e.g. 'VARCHAR(255)' or 'int unsigned'.
+		dataType = dataType[:i]
+	}
+	category, ok := dataTypeCategories[dataType]
+	if !ok || reflect.PtrTo(typ).Implements(scannerType) {
+		return true
+	}
+	var categories []string
+	switch {
+	case typ == reflect.TypeOf(time.Time{}):
+		categories = []string{"time"}
+	case typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8:
+		categories = []string{"bytes", "text"}
+	case typ.Kind() == reflect.String:
+		categories = []string{"text", "decimal"}
+	case typ.Kind() == reflect.Bool:
+		categories = []string{"bool", "integer"}
+	case typ.Kind() >= reflect.Int && typ.Kind() <= reflect.Uint64:
+		categories = []string{"integer", "decimal", "bool"}
+	case typ.Kind() == reflect.Float32 || typ.Kind() == reflect.Float64:
+		categories = []string{"float", "decimal", "integer"}
+	default:
+		return true
+	}
+	for _, compatible := range categories {
+		if category == compatible {
+			return true
+		}
+	}
+	return false
+}
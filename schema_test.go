@@ -0,0 +1,184 @@
+package database_test
+
+import (
+	. "github.com/dtucker2/database"
+	"github.com/dtucker2/database/query"
+
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type objectWithIndex struct {
+	Id   int    `name:"id" type:"auto-increment"`
+	Name string `name:"name" size:"64" index:"true"`
+}
+
+func (obj *objectWithIndex) GetTableName() string {
+	return "objects"
+}
+
+func TestDatabase_CreateTable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE `objects` (`id` BIGINT NOT NULL AUTO_INCREMENT," +
+		"`name` VARCHAR(64) NOT NULL,PRIMARY KEY (`id`))")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("CREATE INDEX `idx_objects_name` ON `objects` (`name`)")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("DROP TABLE `objects`")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	database := NewDatabase(db)
+	require.NoError(t, database.CreateTable(&objectWithIndex{}))
+	require.NoError(t, database.DropTable(&objectWithIndex{}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDatabase_CreateTable_Error(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	mock.ExpectExec("CREATE TABLE `objects`").
+		WillReturnError(errors.New("table exists"))
+	err = NewDatabase(db).CreateTable(&objectWithIndex{})
+	assert.IsType(t, &QueryError{}, err)
+	// The indexes are not created once creating the table fails.
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTx_CreateTable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE TABLE `objects`").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE INDEX `idx_objects_name`").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DROP TABLE `objects`").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	tx, err := NewDatabase(db).Begin()
+	require.NoError(t, err)
+	require.NoError(t, tx.CreateTable(&objectWithIndex{}))
+	require.NoError(t, tx.DropTable(&objectWithIndex{}))
+	require.NoError(t, tx.Commit())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+type objectWithSchema struct {
+	Id        int        `name:"id" key:"true"`
+	Name      string     `name:"name"`
+	Email     *string    `name:"email"`
+	Age       int        `name:"age"`
+	Score     float64    `name:"score"`
+	CreatedAt time.Time  `name:"created_at"`
+	DeletedAt *time.Time `name:"deleted_at"`
+}
+
+func (obj *objectWithSchema) GetTableName() string {
+	return "people"
+}
+
+func TestDatabase_VerifySchema(t *testing.T) {
+	columns := []string{"column_name", "data_type", "nullable"}
+	t.Run("match", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("SELECT column_name, data_type, CASE WHEN is_nullable = 'YES' THEN 1 ELSE 0 END "+
+			"FROM information_schema.columns WHERE table_schema = COALESCE\\(NULLIF\\(\\?, ''\\), DATABASE\\(\\)\\) "+
+			"AND table_name = \\? ORDER BY ordinal_position").
+			WithArgs("", "people").
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("id", "int", 0).
+				AddRow("name", "varchar", 0).
+				AddRow("email", "varchar", 1).
+				AddRow("age", "tinyint", 0).
+				AddRow("score", "decimal", 0).
+				AddRow("created_at", "timestamp", 0).
+				AddRow("deleted_at", "datetime", 1))
+		require.NoError(t, NewDatabase(db).VerifySchema(&objectWithSchema{}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("differences", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("FROM information_schema.columns").
+			WithArgs("", "people").
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("id", "int", 0).
+				AddRow("name", "varchar", 1).
+				AddRow("age", "varchar", 0).
+				AddRow("score", "double", 0).
+				AddRow("created_at", "timestamp", 0).
+				AddRow("deleted_at", "datetime", 1).
+				AddRow("nickname", "varchar", 1))
+		mock.ExpectQuery("FROM information_schema.columns").
+			WithArgs("", "objects").
+			WillReturnRows(sqlmock.NewRows(columns))
+		err = NewDatabase(db).VerifySchema(&objectWithSchema{}, &objectWithIndex{})
+		require.IsType(t, &SchemaError{}, err)
+		assert.Equal(t, []SchemaDifference{
+			{
+				Kind:      NullableColumn,
+				Table:     "people",
+				Column:    "name",
+				Field:     "Name",
+				FieldType: reflect.TypeOf(""),
+				DataType:  "varchar",
+			},
+			{
+				Kind:      MissingColumn,
+				Table:     "people",
+				Column:    "email",
+				Field:     "Email",
+				FieldType: reflect.TypeOf((*string)(nil)),
+			},
+			{
+				Kind:      IncompatibleType,
+				Table:     "people",
+				Column:    "age",
+				Field:     "Age",
+				FieldType: reflect.TypeOf(0),
+				DataType:  "varchar",
+			},
+			{
+				Kind:     ExtraColumn,
+				Table:    "people",
+				Column:   "nickname",
+				DataType: "varchar",
+			},
+			{
+				Kind:  MissingTable,
+				Table: "objects",
+			},
+		}, err.(*SchemaError).Differences)
+		assert.Equal(t, "Schema does not match structs ("+
+			"column 'name' of table 'people' is nullable but field 'Name' (string) cannot hold NULL; "+
+			"column 'email' of table 'people' does not exist (field 'Email'); "+
+			"column 'age' of table 'people' has type 'varchar' incompatible with field 'Age' (int); "+
+			"column 'nickname' of table 'people' is not mapped to a field; "+
+			"table 'objects' does not exist).", err.Error())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("FROM pragma_table_info").
+			WithArgs("objects", "").
+			WillReturnRows(sqlmock.NewRows([]string{"name", "type", "nullable"}).
+				AddRow("id", "INTEGER", 0).
+				AddRow("name", "VARCHAR(64)", 0))
+		require.NoError(t, NewDatabase(db, WithDialect(query.SQLite)).VerifySchema(&objectWithIndex{}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("FROM information_schema.columns").WillReturnError(errors.New("access denied"))
+		err = NewDatabase(db).VerifySchema(&objectWithSchema{})
+		assert.IsType(t, &QueryError{}, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
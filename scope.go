@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+
+	"github.com/dtucker2/database/query"
+)
+
+// Scope is a chainable query against a model's table, created using Database.Model, e.g.
+//
+//	db.Model(&Person{}).Where("age > ?", 30).OrderBy("name").Limit(10).Find(&people)
+//
+// Every method returns a new Scope, so a Scope can be safely reused as the base of several queries.
+type Scope struct {
+	db       *Database
+	tx       *Tx
+	model    interface{}
+	criteria *query.Criteria
+	preloads []string
+}
+
+// Model returns a Scope for querying the table of the passed object (struct pointer).
+func (db *Database) Model(model interface{}) *Scope {
+	return &Scope{
+		db:       db,
+		model:    model,
+		criteria: query.NewCriteria(),
+	}
+}
+
+// Model returns a Scope for querying the table of the passed object (struct pointer) within the transaction.
+func (tx *Tx) Model(model interface{}) *Scope {
+	return &Scope{
+		db:       tx.db,
+		tx:       tx,
+		model:    model,
+		criteria: query.NewCriteria(),
+	}
+}
+
+// Where adds a condition which must be met in addition to any previous conditions. The condition is either a clause
+// using '?' placeholders followed by its arguments, e.g. Where("age > ?", 30), or a struct pointer whose non-zero
+// fields are matched for equality, e.g. Where(&Person{Age: 30}).
+func (scope *Scope) Where(clause interface{}, args ...interface{}) *Scope {
+	return scope.with(scope.criteria.Where(clause, args...))
+}
+
+// OrWhere adds a condition which may be met instead of the previous conditions. It accepts the same conditions as Where.
+func (scope *Scope) OrWhere(clause interface{}, args ...interface{}) *Scope {
+	return scope.with(scope.criteria.OrWhere(clause, args...))
+}
+
+// OrderBy adds columns to sort by. Each column may be followed by 'ASC' or 'DESC', e.g. OrderBy("age DESC", "name").
+func (scope *Scope) OrderBy(columns ...string) *Scope {
+	return scope.with(scope.criteria.OrderBy(columns...))
+}
+
+// Limit restricts the number of rows returned.
+func (scope *Scope) Limit(limit int) *Scope {
+	return scope.with(scope.criteria.Limit(limit))
+}
+
+// Offset skips the passed number of rows before returning any.
+func (scope *Scope) Offset(offset int) *Scope {
+	return scope.with(scope.criteria.Offset(offset))
+}
+
+// Preload loads the passed relations (fields tagged with 'rel') of every struct found, using one extra query per
+// relation. Relations of relations are loaded using dotted paths, e.g. Preload("LineItems.Product").
+func (scope *Scope) Preload(relations ...string) *Scope {
+	clone := scope.with(scope.criteria)
+	clone.preloads = append(append([]string(nil), scope.preloads...), relations...)
+	return clone
+}
+
+// WithTrashed includes soft deleted rows, which are otherwise excluded from tables with a deleted_at column.
+func (scope *Scope) WithTrashed() *Scope {
+	return scope.with(scope.criteria.WithTrashed())
+}
+
+// Find executes the query, replacing the contents of dest (a pointer to a slice of structs or struct pointers) with
+// the resulting rows.
+func (scope *Scope) Find(dest interface{}) error {
+	return scope.FindContext(context.Background(), dest)
+}
+
+// FindContext is the same as Find but executes the query using the passed context.
+func (scope *Scope) FindContext(ctx context.Context, dest interface{}) error {
+	if scope.tx != nil {
+		ctx = scope.tx.withContext(ctx)
+	}
+	return scope.db.findCriteria(ctx, scope.exec(), scope.model, dest, scope.criteria, scope.preloads)
+}
+
+// Count executes the query, returning the number of matching rows. Any ordering and row limits are ignored.
+func (scope *Scope) Count() (int64, error) {
+	return scope.CountContext(context.Background())
+}
+
+// CountContext is the same as Count but executes the query using the passed context.
+func (scope *Scope) CountContext(ctx context.Context) (int64, error) {
+	return scope.db.count(ctx, scope.exec(), scope.model, scope.criteria)
+}
+
+func (scope *Scope) with(criteria *query.Criteria) *Scope {
+	return &Scope{
+		db:       scope.db,
+		tx:       scope.tx,
+		model:    scope.model,
+		criteria: criteria,
+		preloads: scope.preloads,
+	}
+}
+
+func (scope *Scope) exec() executor {
+	if scope.tx != nil {
+		return scope.tx.Tx
+	}
+	return scope.db.DB
+}
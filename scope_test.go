@@ -0,0 +1,49 @@
+package database_test
+
+import (
+	. "github.com/dtucker2/database"
+
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScope_Find(t *testing.T) {
+	t.Run("chained", func(t *testing.T) {
+		objs := []objectWithTags{}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("SELECT `id`,`name`,`created_at`,`updated_at` FROM `objects` WHERE \\(id > \\?\\) OR \\(`name`=\\?\\) ORDER BY `name` LIMIT 10 OFFSET 20").
+			WithArgs(1, "Test Object").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at", "updated_at"}).
+				AddRow(2, "Test Object", (*time.Time)(nil), (*time.Time)(nil)))
+		require.NoError(t, NewDatabase(db).
+			Model(&objectWithTags{}).
+			Where("id > ?", 1).
+			OrWhere(&objectWithTags{Name: "Test Object"}).
+			OrderBy("name").
+			Limit(10).
+			Offset(20).
+			Find(&objs))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, []objectWithTags{{Id: 2, Name: "Test Object"}}, objs)
+	})
+	t.Run("reused", func(t *testing.T) {
+		objs := []objectWithTags{}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectQuery("SELECT `id`,`name`,`created_at`,`updated_at` FROM `objects` WHERE \\(id > \\?\\) AND \\(id < \\?\\)").
+			WithArgs(1, 5).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at", "updated_at"}))
+		mock.ExpectQuery("SELECT `id`,`name`,`created_at`,`updated_at` FROM `objects` WHERE \\(id > \\?\\) AND \\(id > \\?\\)").
+			WithArgs(1, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at", "updated_at"}))
+		base := NewDatabase(db).Model(&objectWithTags{}).Where("id > ?", 1)
+		require.NoError(t, base.Where("id < ?", 5).Find(&objs))
+		require.NoError(t, base.Where("id > ?", 10).Find(&objs))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dtucker2/database/query"
+)
+
+// snapshots holds the Snapshot of every tracked object, keyed by struct pointer. It is shared by copies of a Database
+// (see Unscoped) and its transactions. A map guarded by a mutex is used rather than a sync.Map, which may keep deleted
+// keys, and so the tracked structs, reachable.
+type snapshots struct {
+	mutex   sync.RWMutex
+	objects map[interface{}]query.Snapshot
+}
+
+func (snapshots *snapshots) load(object interface{}) (query.Snapshot, bool) {
+	snapshots.mutex.RLock()
+	defer snapshots.mutex.RUnlock()
+	snapshot, ok := snapshots.objects[object]
+	return snapshot, ok
+}
+
+func (snapshots *snapshots) store(object interface{}, snapshot query.Snapshot) {
+	snapshots.mutex.Lock()
+	defer snapshots.mutex.Unlock()
+	if snapshots.objects == nil {
+		snapshots.objects = make(map[interface{}]query.Snapshot)
+	}
+	snapshots.objects[object] = snapshot
+}
+
+func (snapshots *snapshots) delete(object interface{}) {
+	snapshots.mutex.Lock()
+	defer snapshots.mutex.Unlock()
+	delete(snapshots.objects, object)
+}
+
+// Track records a snapshot of the current values of the passed pointer to a struct. Subsequent calls to Update with
+// the same pointer only write the columns whose values have changed since, skipping the query entirely when nothing
+// has changed. The snapshot is refreshed whenever the struct is inserted, selected or updated, and discarded when it is
+// deleted or passed to Untrack. Snapshots refreshed or discarded within a transaction are restored if it is rolled back.
+//
+// The Database holds a reference to every tracked struct until it is deleted or untracked, so Untrack must be called
+// once a struct is no longer used (e.g. at the end of a request) to allow it to be garbage collected.
+func (db *Database) Track(object interface{}) error {
+	snapshot, err := db.TakeSnapshot(object)
+	if err != nil {
+		return err
+	}
+	db.snapshots.store(object, snapshot)
+	return nil
+}
+
+// Untrack discards the snapshot of the passed pointer to a struct, so that Update writes every column again.
+func (db *Database) Untrack(object interface{}) {
+	db.snapshots.delete(object)
+}
+
+// Track records a snapshot of the current values of the passed pointer to a struct. See Database.Track.
+func (tx *Tx) Track(object interface{}) error {
+	return tx.db.Track(object)
+}
+
+// Untrack discards the snapshot of the passed pointer to a struct. See Database.Untrack.
+func (tx *Tx) Untrack(object interface{}) {
+	tx.db.Untrack(object)
+}
+
+// isTracked reports whether a snapshot has been recorded for the passed object.
+func (db *Database) isTracked(object interface{}) bool {
+	_, ok := db.snapshots.load(object)
+	return ok
+}
+
+// untrack discards the snapshot of the passed object, keeping it to be restored if the transaction carried by the passed
+// context, if any, is rolled back.
+func (db *Database) untrack(ctx context.Context, object interface{}) {
+	keepSnapshot(ctx, object)
+	db.Untrack(object)
+}
+
+// refreshSnapshotColumns replaces the passed columns of the snapshot of the passed object, if it is tracked, with their
+// current values.
+func (db *Database) refreshSnapshotColumns(ctx context.Context, object interface{}, columns []string) error {
+	previous, ok := db.snapshots.load(object)
+	if !ok {
+		return nil
+	}
+	keepSnapshot(ctx, object)
+	current, err := db.TakeSnapshot(object)
+	if err != nil {
+		return err
+	}
+	// Snapshots are replaced rather than modified as they may be read concurrently.
+	snapshot := make(query.Snapshot, len(current))
+	for column, value := range previous {
+		snapshot[column] = value
+	}
+	for _, column := range columns {
+		if value, ok := current[column]; ok {
+			snapshot[column] = value
+		}
+	}
+	db.snapshots.store(object, snapshot)
+	return nil
+}
+
+// refreshSnapshot replaces the snapshot of the passed object, if it is tracked, with its current values.
+func (db *Database) refreshSnapshot(ctx context.Context, object interface{}) error {
+	if !db.isTracked(object) {
+		return nil
+	}
+	keepSnapshot(ctx, object)
+	return db.Track(object)
+}
+
+// keepSnapshot records the current snapshot of the passed object within the transaction carried by the passed context,
+// if any, the first time it is replaced or discarded there, so that it can be restored if the transaction is rolled back.
+func keepSnapshot(ctx context.Context, object interface{}) {
+	tx, ok := TxFromContext(ctx)
+	if !ok {
+		return
+	}
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+	if _, ok := tx.snapshots[object]; ok {
+		return
+	}
+	if tx.snapshots == nil {
+		tx.snapshots = make(map[interface{}]query.Snapshot)
+	}
+	// A nil snapshot records that the object was not tracked.
+	snapshot, _ := tx.db.snapshots.load(object)
+	tx.snapshots[object] = snapshot
+}
+
+// releaseSnapshots drops the snapshots kept by keepSnapshot once the transaction has ended, first restoring them when
+// it has been rolled back, so that they match the rows it leaves behind.
+func (tx *Tx) releaseSnapshots(restore bool) {
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+	if restore {
+		for object, snapshot := range tx.snapshots {
+			if snapshot == nil {
+				tx.db.snapshots.delete(object)
+			} else {
+				tx.db.snapshots.store(object, snapshot)
+			}
+		}
+	}
+	tx.snapshots = nil
+}
@@ -0,0 +1,225 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/dtucker2/database/query"
+)
+
+// Tx wraps a sql.Tx object and provides the same struct based methods as Database, executed within the transaction.
+type Tx struct {
+	*sql.Tx
+	db *Database
+
+	mutex     sync.Mutex
+	snapshots map[interface{}]query.Snapshot
+}
+
+// txKey is the context key of the transaction passed to hooks, see TxFromContext.
+type txKey struct{}
+
+// TxFromContext returns the transaction of the operation calling a hook from the context passed to the hook. Hooks
+// should execute their own queries within it, so that they are committed or rolled back along with the operation.
+// It returns false when the operation is not executed within a transaction.
+func TxFromContext(ctx context.Context) (*Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(*Tx)
+	return tx, ok
+}
+
+// withContext returns a copy of the passed context carrying the transaction, see TxFromContext.
+func (tx *Tx) withContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// Begin starts a transaction using a background context.
+func (db *Database) Begin() (*Tx, error) {
+	return db.BeginTx(context.Background(), nil)
+}
+
+// BeginTx starts a transaction using the passed context and options.
+func (db *Database) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	tx, err := db.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to begin transaction.")
+	}
+	return &Tx{Tx: tx, db: db}, nil
+}
+
+// WithTransaction begins a transaction and passes it to fn. The transaction is committed if fn returns nil and
+// rolled back if fn returns an error or panics. Panics are re-raised once the transaction has been rolled back.
+func (db *Database) WithTransaction(ctx context.Context, fn func(tx *Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return errors.Wrapf(err, "Failed to roll back transaction (%s).", rollbackErr)
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "Failed to commit transaction.")
+	}
+	return nil
+}
+
+// Commit commits the transaction. Snapshots of tracked structs replaced within the transaction are restored if it
+// fails, see Database.Track.
+func (tx *Tx) Commit() error {
+	err := tx.Tx.Commit()
+	tx.releaseSnapshots(err != nil)
+	return err
+}
+
+// Rollback aborts the transaction, restoring the snapshots of tracked structs replaced within it, see Database.Track.
+func (tx *Tx) Rollback() error {
+	tx.releaseSnapshots(true)
+	return tx.Tx.Rollback()
+}
+
+// Insert constructs and executes an insert query within the transaction using only the passed pointer to a struct.
+func (tx *Tx) Insert(object interface{}) error {
+	return tx.InsertContext(context.Background(), object)
+}
+
+// InsertContext is the same as Insert but executes the query using the passed context.
+func (tx *Tx) InsertContext(ctx context.Context, object interface{}) error {
+	return tx.db.insert(tx.withContext(ctx), tx.Tx, object)
+}
+
+// InsertMany constructs and executes multi-row insert queries within the transaction for every struct in the passed
+// slice, returning the total number of rows inserted. See Database.InsertMany.
+func (tx *Tx) InsertMany(objects interface{}) (int64, error) {
+	return tx.InsertManyContext(context.Background(), objects)
+}
+
+// InsertManyContext is the same as InsertMany but executes the queries using the passed context.
+func (tx *Tx) InsertManyContext(ctx context.Context, objects interface{}) (int64, error) {
+	ctx = tx.withContext(ctx)
+	batches, err := tx.db.buildInsertBatches(ctx, objects)
+	if err != nil {
+		return 0, err
+	}
+	return tx.db.executeInsertBatches(ctx, tx.Tx, objects, batches)
+}
+
+// Upsert constructs and executes an upsert query within the transaction using only the passed pointer to a struct.
+func (tx *Tx) Upsert(object interface{}, columns ...string) error {
+	return tx.UpsertContext(context.Background(), object, columns...)
+}
+
+// UpsertContext is the same as Upsert but executes the query using the passed context.
+func (tx *Tx) UpsertContext(ctx context.Context, object interface{}, columns ...string) error {
+	return tx.db.upsert(tx.withContext(ctx), tx.Tx, object, columns)
+}
+
+// Update constructs and executes an update query within the transaction using only the passed pointer to a struct.
+func (tx *Tx) Update(object interface{}) error {
+	return tx.UpdateContext(context.Background(), object)
+}
+
+// UpdateContext is the same as Update but executes the query using the passed context.
+func (tx *Tx) UpdateContext(ctx context.Context, object interface{}) error {
+	return tx.db.update(tx.withContext(ctx), tx.Tx, object)
+}
+
+// UpdateColumns constructs and executes an update query within the transaction which only writes the passed columns of
+// the passed pointer to a struct. See Database.UpdateColumns.
+func (tx *Tx) UpdateColumns(object interface{}, columns ...string) error {
+	return tx.UpdateColumnsContext(context.Background(), object, columns...)
+}
+
+// UpdateColumnsContext is the same as UpdateColumns but executes the query using the passed context.
+func (tx *Tx) UpdateColumnsContext(ctx context.Context, object interface{}, columns ...string) error {
+	return tx.db.updateColumns(tx.withContext(ctx), tx.Tx, object, columns)
+}
+
+// UpdateMap constructs and executes an update query within the transaction which writes the passed values to the row
+// of the passed pointer to a struct. See Database.UpdateMap.
+func (tx *Tx) UpdateMap(object interface{}, values map[string]interface{}) error {
+	return tx.UpdateMapContext(context.Background(), object, values)
+}
+
+// UpdateMapContext is the same as UpdateMap but executes the query using the passed context.
+func (tx *Tx) UpdateMapContext(ctx context.Context, object interface{}, values map[string]interface{}) error {
+	return tx.db.updateMap(tx.withContext(ctx), tx.Tx, object, values)
+}
+
+// Delete constructs and executes a delete query within the transaction using only the passed pointer to a struct.
+func (tx *Tx) Delete(object interface{}) error {
+	return tx.DeleteContext(context.Background(), object)
+}
+
+// DeleteContext is the same as Delete but executes the query using the passed context.
+func (tx *Tx) DeleteContext(ctx context.Context, object interface{}) error {
+	return tx.db.delete(tx.withContext(ctx), tx.Tx, object)
+}
+
+// ForceDelete constructs and executes a delete query within the transaction using only the passed pointer to a struct,
+// physically deleting the row even when the struct supports soft deletes.
+func (tx *Tx) ForceDelete(object interface{}) error {
+	return tx.ForceDeleteContext(context.Background(), object)
+}
+
+// ForceDeleteContext is the same as ForceDelete but executes the query using the passed context.
+func (tx *Tx) ForceDeleteContext(ctx context.Context, object interface{}) error {
+	return tx.db.forceDelete(tx.withContext(ctx), tx.Tx, object)
+}
+
+// Restore constructs and executes a query within the transaction restoring the soft deleted row of the passed pointer
+// to a struct.
+func (tx *Tx) Restore(object interface{}) error {
+	return tx.RestoreContext(context.Background(), object)
+}
+
+// RestoreContext is the same as Restore but executes the query using the passed context.
+func (tx *Tx) RestoreContext(ctx context.Context, object interface{}) error {
+	return tx.db.restore(tx.withContext(ctx), tx.Tx, object)
+}
+
+// Unscoped returns a copy of the transaction which ignores soft deletes. See Database.Unscoped.
+func (tx *Tx) Unscoped() *Tx {
+	return &Tx{Tx: tx.Tx, db: tx.db.Unscoped()}
+}
+
+// Select constructs and executes a select query within the transaction using only the passed pointer to a struct.
+func (tx *Tx) Select(object interface{}, options ...SelectOption) error {
+	return tx.SelectContext(context.Background(), object, options...)
+}
+
+// SelectContext is the same as Select but executes the query using the passed context.
+func (tx *Tx) SelectContext(ctx context.Context, object interface{}, options ...SelectOption) error {
+	return tx.db.selectOne(tx.withContext(ctx), tx.Tx, object, newSelectOptions(options))
+}
+
+// Find constructs and executes a select query within the transaction for every row matching the passed conditions.
+func (tx *Tx) Find(dest interface{}, conditions ...interface{}) error {
+	return tx.FindContext(context.Background(), dest, conditions...)
+}
+
+// FindContext is the same as Find but executes the query using the passed context.
+func (tx *Tx) FindContext(ctx context.Context, dest interface{}, conditions ...interface{}) error {
+	return tx.db.find(tx.withContext(ctx), tx.Tx, dest, conditions)
+}
+
+// Count constructs and executes a query within the transaction counting the rows of the passed model's table matching
+// the passed conditions.
+func (tx *Tx) Count(model interface{}, conditions ...interface{}) (int64, error) {
+	return tx.CountContext(context.Background(), model, conditions...)
+}
+
+// CountContext is the same as Count but executes the query using the passed context.
+func (tx *Tx) CountContext(ctx context.Context, model interface{}, conditions ...interface{}) (int64, error) {
+	return tx.db.count(tx.withContext(ctx), tx.Tx, model, newConditionCriteria(conditions))
+}
@@ -0,0 +1,103 @@
+package database_test
+
+import (
+	. "github.com/dtucker2/database"
+
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTx(t *testing.T) {
+	t.Run("commit", func(t *testing.T) {
+		obj := objectWithTags{
+			Id:   1,
+			Name: "Test Object",
+		}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `objects` \\(`name`,`created_at`\\) VALUES \\(\\?,\\?\\)").
+			WithArgs("Test Object", anyTime{}).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("UPDATE `objects` SET `name`=\\?,`updated_at`=\\? WHERE `id`=\\?").
+			WithArgs("Test Object", anyTime{}, 1).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery("SELECT `id`,`name`,`created_at`,`updated_at` FROM `objects` WHERE `id`=\\?").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at", "updated_at"}).
+				AddRow(1, "Test Object", (*time.Time)(nil), (*time.Time)(nil)))
+		mock.ExpectExec("DELETE FROM `objects` WHERE `id`=\\?").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		tx, err := NewDatabase(db).Begin()
+		require.NoError(t, err)
+		require.NoError(t, tx.Insert(&obj))
+		require.NoError(t, tx.Update(&obj))
+		require.NoError(t, tx.Select(&obj))
+		require.NoError(t, tx.Delete(&obj))
+		require.NoError(t, tx.Commit())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("begin error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectBegin().WillReturnError(fmt.Errorf("Something terrible happened!"))
+		_, err = NewDatabase(db).Begin()
+		require.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDatabase_WithTransaction(t *testing.T) {
+	t.Run("commit", func(t *testing.T) {
+		obj := objectWithTags{
+			Name: "Test Object",
+		}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `objects` \\(`name`,`created_at`\\) VALUES \\(\\?,\\?\\)").
+			WithArgs("Test Object", anyTime{}).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		require.NoError(t, NewDatabase(db).WithTransaction(context.Background(), func(tx *Tx) error {
+			return tx.Insert(&obj)
+		}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("rollback", func(t *testing.T) {
+		obj := objectWithTags{
+			Name: "Test Object",
+		}
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `objects` \\(`name`,`created_at`\\) VALUES \\(\\?,\\?\\)").
+			WithArgs("Test Object", anyTime{}).
+			WillReturnError(fmt.Errorf("Something terrible happened!"))
+		mock.ExpectRollback()
+		require.Error(t, NewDatabase(db).WithTransaction(context.Background(), func(tx *Tx) error {
+			return tx.Insert(&obj)
+		}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("panic", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		assert.Panics(t, func() {
+			NewDatabase(db).WithTransaction(context.Background(), func(tx *Tx) error {
+				panic("Something terrible happened!")
+			})
+		})
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}